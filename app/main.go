@@ -109,6 +109,17 @@ func main() {
 		return
 	}
 
+	// Load access requirement of routes, every required privilege must be registered
+	routePolicies, err := middleware.LoadRoutePolicies(routek.DefaultRouteFile)
+	if err != nil {
+		rootLog.Fatal("Failed to load route policies", logkOption.Error(errk.Trace(err)))
+		return
+	}
+	if err := coreServer.ValidateRoutePrivileges(routePolicies.Privileges()); err != nil {
+		rootLog.Fatal("Invalid route privileges", logkOption.Error(errk.Trace(err)))
+		return
+	}
+
 	// Swagger Handler Wrapper
 	swaggerHandler := fasthttpSwagger.WrapHandler(fasthttpSwagger.URL("/swagger/doc.json"))
 
@@ -126,6 +137,8 @@ func main() {
 		RateLimitRPS:     cfg.RateLimitRPS,
 		RateLimitBurst:   cfg.RateLimitBurst,
 		CORSAllowOrigins: cfg.CORSAllowOrigins,
+		Authenticator:    coreServer.Authenticate,
+		RoutePolicies:    routePolicies,
 	})
 	if err != nil {
 		rootLog.Fatal("Failed to init middleware", logkOption.Error(errk.Trace(err)))
@@ -7,6 +7,7 @@ import (
 
 	"github.com/joho/godotenv"
 	"github.com/kelseyhightower/envconfig"
+	"golang.org/x/crypto/bcrypt"
 )
 
 type Config struct {
@@ -31,21 +32,113 @@ type Config struct {
 	UserSessionLifetime        int64  `envconfig:"USER_SESSION_LIFETIME" default:"3600"`
 	UserSessionRefreshLifetime int64  `envconfig:"USER_SESSION_REFRESH_LIFETIME" default:"2592000"`
 
+	// Seconds a rotated refresh token is still accepted from the same device, tolerating concurrent refreshes
+	RefreshTokenReuseGracePeriod int64 `envconfig:"REFRESH_TOKEN_REUSE_GRACE_PERIOD" default:"10"`
+
+	// JWT asymmetric signing (RS256/EdDSA) PEM private key, previous public keys are kid:path pairs.
+	// With a signing key, JWT_VERIFY_LEGACY_SECRET=false stops accepting tokens signed with JWT_SECRET, retiring it
+	JwtSigningKeyFile     string            `envconfig:"JWT_SIGNING_KEY_FILE" default:""`
+	JwtSigningKeyId       string            `envconfig:"JWT_SIGNING_KEY_ID" default:""`
+	JwtVerificationKeys   map[string]string `envconfig:"JWT_VERIFICATION_KEYS" default:""`
+	JwtVerifyLegacySecret bool              `envconfig:"JWT_VERIFY_LEGACY_SECRET" default:"true"`
+
 	FeatureFlagSingleDevice bool `envconfig:"FEATURE_FLAG_SINGLE_DEVICE" default:"false"`
 	FeatureFlagUUPDP        bool `envconfig:"FEATURE_FLAG_UUPDP" default:"false"`
 
+	// Scope single device to each device platform, e.g. allow one phone and one browser
+	SingleDevicePerPlatform bool `envconfig:"SINGLE_DEVICE_PER_PLATFORM" default:"false"`
+
 	CORSAllowOrigins []string `envconfig:"CORS_ALLOW_ORIGINS" default:"*"`
 
 	// OTEL
 	OtelCollectorEndpoint string `envconfig:"OTEL_COLLECTOR_ENDPOINT" default:"localhost:4317"`
 
+	// Message delivery (log, file or memory), file sender appends JSON lines to MESSAGE_SENDER_FILE.
+	// MESSAGE_SENDER_EMAIL and MESSAGE_SENDER_SMS override the sender of a channel
+	MessageSender      string `envconfig:"MESSAGE_SENDER" default:"log"`
+	MessageSenderFile  string `envconfig:"MESSAGE_SENDER_FILE" default:"messages.log"`
+	MessageSenderEmail string `envconfig:"MESSAGE_SENDER_EMAIL" default:""`
+	MessageSenderSms   string `envconfig:"MESSAGE_SENDER_SMS" default:""`
+
+	// Hashing of passwords (argon2id or bcrypt), argon2id memory is in KiB. Client secrets are always hashed with bcrypt.
+	// Hashes of the other algorithm or outdated parameters are still verified, and upgraded on successful login
+	PasswordHashAlgorithm string `envconfig:"PASSWORD_HASH_ALGORITHM" default:"argon2id"`
+	Argon2Memory          uint32 `envconfig:"ARGON2_MEMORY" default:"65536"`
+	Argon2Iterations      uint32 `envconfig:"ARGON2_ITERATIONS" default:"3"`
+	Argon2Parallelism     uint8  `envconfig:"ARGON2_PARALLELISM" default:"2"`
+	Argon2SaltLength      uint32 `envconfig:"ARGON2_SALT_LENGTH" default:"16"`
+	Argon2KeyLength       uint32 `envconfig:"ARGON2_KEY_LENGTH" default:"32"`
+	BcryptCost            int    `envconfig:"BCRYPT_COST" default:"10"`
+
+	// Password policy of new passwords. PASSWORD_BREACHED_LIST_FILE has a breached password per line, in plain text
+	// or as SHA-1 hex digest optionally followed by ":<count>", e.g. the Have I Been Pwned dump. Empty disables the check
+	PasswordMinLength          int    `envconfig:"PASSWORD_MIN_LENGTH" default:"8"`
+	PasswordRequireLowercase   bool   `envconfig:"PASSWORD_REQUIRE_LOWERCASE" default:"false"`
+	PasswordRequireUppercase   bool   `envconfig:"PASSWORD_REQUIRE_UPPERCASE" default:"false"`
+	PasswordRequireDigit       bool   `envconfig:"PASSWORD_REQUIRE_DIGIT" default:"false"`
+	PasswordRequireSymbol      bool   `envconfig:"PASSWORD_REQUIRE_SYMBOL" default:"false"`
+	PasswordRejectPersonalInfo bool   `envconfig:"PASSWORD_REJECT_PERSONAL_INFO" default:"true"`
+	PasswordBreachedListFile   string `envconfig:"PASSWORD_BREACHED_LIST_FILE" default:""`
+
+	// Seconds the previous client secret is still accepted after rotation, so clients can be redeployed without downtime
+	ClientSecretRotationOverlap int64 `envconfig:"CLIENT_SECRET_ROTATION_OVERLAP" default:"86400"`
+
+	// Admin impersonation session lifetime in seconds. Impersonation sessions are granted with privileges of USER role
+	// listed in IMPERSONATION_PRIVILEGES, impersonation is disabled while it is empty. They are never granted a refresh token
+	ImpersonationSessionLifetime int64    `envconfig:"IMPERSONATION_SESSION_LIFETIME" default:"900"`
+	ImpersonationPrivileges      []string `envconfig:"IMPERSONATION_PRIVILEGES" default:""`
+
+	// Password reset token lifetime in seconds, reset link is PASSWORD_RESET_URL?token=<token> when set
+	PasswordResetTokenLifetime int64  `envconfig:"PASSWORD_RESET_TOKEN_LIFETIME" default:"900"`
+	PasswordResetUrl           string `envconfig:"PASSWORD_RESET_URL" default:""`
+
+	// Identifier verification code lifetime in seconds. When verification is required, registered users stay PENDING
+	// and password login is blocked until an email or phone identifier is verified. An identifier can request a code once
+	// per VERIFICATION_RESEND_INTERVAL seconds and VERIFICATION_MAX_REQUESTS times per VERIFICATION_REQUEST_WINDOW seconds
+	VerificationCodeLifetime   int64 `envconfig:"VERIFICATION_CODE_LIFETIME" default:"900"`
+	VerificationMaxAttempts    int   `envconfig:"VERIFICATION_MAX_ATTEMPTS" default:"5"`
+	VerificationResendInterval int64 `envconfig:"VERIFICATION_RESEND_INTERVAL" default:"60"`
+	VerificationMaxRequests    int   `envconfig:"VERIFICATION_MAX_REQUESTS" default:"5"`
+	VerificationRequestWindow  int64 `envconfig:"VERIFICATION_REQUEST_WINDOW" default:"3600"`
+	RequireVerifiedIdentifier  bool  `envconfig:"REQUIRE_VERIFIED_IDENTIFIER" default:"false"`
+
+	// Password login throttling. Each failure of an identifier delays its next attempt by LOGIN_FAILURE_DELAY seconds, doubled
+	// per failure up to LOGIN_MAX_FAILURE_DELAY. The account is locked for LOGIN_LOCKOUT_DURATION seconds after
+	// LOGIN_MAX_FAILED_ATTEMPTS failures, and an IP is blocked after LOGIN_IP_MAX_FAILED_ATTEMPTS failures within LOGIN_FAILURE_WINDOW
+	LoginMaxFailedAttempts   int   `envconfig:"LOGIN_MAX_FAILED_ATTEMPTS" default:"5"`
+	LoginIpMaxFailedAttempts int   `envconfig:"LOGIN_IP_MAX_FAILED_ATTEMPTS" default:"20"`
+	LoginFailureWindow       int64 `envconfig:"LOGIN_FAILURE_WINDOW" default:"900"`
+	LoginFailureDelay        int64 `envconfig:"LOGIN_FAILURE_DELAY" default:"1"`
+	LoginMaxFailureDelay     int64 `envconfig:"LOGIN_MAX_FAILURE_DELAY" default:"30"`
+	LoginLockoutDuration     int64 `envconfig:"LOGIN_LOCKOUT_DURATION" default:"900"`
+
+	// TOTP two-factor authentication. TOTP_ISSUER is shown in authenticator apps, defaults to JWT_ISSUER.
+	// Password login of user with TOTP enabled returns a challenge token valid for TWO_FACTOR_CHALLENGE_LIFETIME seconds.
+	// A challenge, and a signed in user managing its second factor within LOGIN_FAILURE_WINDOW, accept up to
+	// TWO_FACTOR_MAX_ATTEMPTS invalid codes
+	TotpIssuer                 string `envconfig:"TOTP_ISSUER" default:""`
+	TwoFactorChallengeLifetime int64  `envconfig:"TWO_FACTOR_CHALLENGE_LIFETIME" default:"300"`
+	TwoFactorMaxAttempts       int    `envconfig:"TWO_FACTOR_MAX_ATTEMPTS" default:"5"`
+	RecoveryCodeCount          int    `envconfig:"RECOVERY_CODE_COUNT" default:"10"`
+
+	// Passwordless login with a one-time code sent to email or phone. An identifier can request a code once per
+	// OTP_LOGIN_RESEND_INTERVAL seconds and OTP_LOGIN_MAX_REQUESTS times per OTP_LOGIN_REQUEST_WINDOW seconds.
+	// Email includes a magic link OTP_LOGIN_URL?identifier=<identifier>&code=<code> when set
+	OtpLoginLifetime       int64  `envconfig:"OTP_LOGIN_LIFETIME" default:"300"`
+	OtpLoginMaxAttempts    int    `envconfig:"OTP_LOGIN_MAX_ATTEMPTS" default:"5"`
+	OtpLoginResendInterval int64  `envconfig:"OTP_LOGIN_RESEND_INTERVAL" default:"60"`
+	OtpLoginMaxRequests    int    `envconfig:"OTP_LOGIN_MAX_REQUESTS" default:"5"`
+	OtpLoginRequestWindow  int64  `envconfig:"OTP_LOGIN_REQUEST_WINDOW" default:"3600"`
+	OtpLoginUrl            string `envconfig:"OTP_LOGIN_URL" default:""`
+
 	// OAuth Configuration
-	GoogleClientID    string `envconfig:"GOOGLE_CLIENT_ID" default:""`
-	FacebookAppID     string `envconfig:"FACEBOOK_APP_ID" default:""`
-	FacebookAppSecret string `envconfig:"FACEBOOK_APP_SECRET" default:""`
-	AppleClientID     string `envconfig:"APPLE_CLIENT_ID" default:""`
-	AppleTeamID       string `envconfig:"APPLE_TEAM_ID" default:""`
-	AppleKeyID        string `envconfig:"APPLE_KEY_ID" default:""`
+	GoogleClientID      string   `envconfig:"GOOGLE_CLIENT_ID" default:""`
+	GoogleHostedDomains []string `envconfig:"GOOGLE_HOSTED_DOMAINS" default:""`
+	FacebookAppID       string   `envconfig:"FACEBOOK_APP_ID" default:""`
+	FacebookAppSecret   string   `envconfig:"FACEBOOK_APP_SECRET" default:""`
+	AppleClientID       string   `envconfig:"APPLE_CLIENT_ID" default:""`
+	AppleTeamID         string   `envconfig:"APPLE_TEAM_ID" default:""`
+	AppleKeyID          string   `envconfig:"APPLE_KEY_ID" default:""`
 
 	DatabaseDriver          string `envconfig:"DB_DRIVER" default:"mysql"`
 	DatabaseHost            string `envconfig:"DB_HOST" default:"localhost"`
@@ -117,6 +210,38 @@ func (c *Config) validate() error {
 		return fmt.Errorf("rate limit values must be greater than zero")
 	}
 
+	switch c.MessageSender {
+	case "log", "file", "memory":
+	default:
+		return fmt.Errorf("unsupported MESSAGE_SENDER '%s'", c.MessageSender)
+	}
+
+	switch c.PasswordHashAlgorithm {
+	case "argon2id", "bcrypt":
+	default:
+		return fmt.Errorf("unsupported PASSWORD_HASH_ALGORITHM '%s'", c.PasswordHashAlgorithm)
+	}
+
+	if c.BcryptCost < bcrypt.MinCost || c.BcryptCost > bcrypt.MaxCost {
+		return fmt.Errorf("BCRYPT_COST must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+	}
+
+	if c.Argon2Memory == 0 || c.Argon2Iterations == 0 || c.Argon2Parallelism == 0 || c.Argon2SaltLength < 8 || c.Argon2KeyLength < 16 {
+		return fmt.Errorf("invalid argon2 parameters")
+	}
+
+	if c.PasswordMinLength < 1 || c.PasswordMinLength > 128 {
+		return fmt.Errorf("PASSWORD_MIN_LENGTH must be between 1 and 128")
+	}
+
+	for key, value := range map[string]string{"MESSAGE_SENDER_EMAIL": c.MessageSenderEmail, "MESSAGE_SENDER_SMS": c.MessageSenderSms} {
+		switch value {
+		case "", "log", "file", "memory":
+		default:
+			return fmt.Errorf("unsupported %s '%s'", key, value)
+		}
+	}
+
 	driver := strings.ToLower(c.DatabaseDriver)
 	switch driver {
 	case "mysql", "mariadb":
@@ -11,27 +11,81 @@ type LoginPassword_Payload struct {
 // ===== Login with OAuth =====
 
 type LoginOAuth_Payload struct {
-	Provider AuthProvider_Enum `json:"provider" validate:"required,oneof=2 3 4"` // 2=GOOGLE, 3=FACEBOOK, 4=APPLE
-	IdToken  string            `json:"idToken" validate:"required"`              // OAuth ID token from provider
+	Provider AuthProvider_Enum `json:"provider" validate:"required"`                    // 2=GOOGLE, 3=FACEBOOK, 4=APPLE
+	IdToken  string            `json:"idToken" validate:"required"`                     // OAuth ID token from provider
+	Nonce    string            `json:"nonce,omitempty" validate:"omitempty,max=255"`    // Raw nonce used to request the ID token, required for Apple
+	FullName string            `json:"fullName,omitempty" validate:"omitempty,max=255"` // Apple only shares user's name on first login
 	Device   *DeviceSession    `json:"device,omitempty" validate:"omitempty"`
 }
 
+type LinkOAuth_Payload struct {
+	Provider AuthProvider_Enum `json:"provider" validate:"required"`                 // 2=GOOGLE, 3=FACEBOOK, 4=APPLE
+	IdToken  string            `json:"idToken" validate:"required"`                  // OAuth ID token from provider
+	Nonce    string            `json:"nonce,omitempty" validate:"omitempty,max=255"` // Raw nonce used to request the ID token, required for Apple
+}
+
 // ===== Register User =====
 
 type RegisterUser_Payload struct {
-	FullName string `json:"fullName" validate:"required,min=2,max=255"`
-	Email    string `json:"email" validate:"omitempty,email,max=255"`
-	Phone    string `json:"phone" validate:"omitempty,max=20"`
-	Username string `json:"username" validate:"omitempty,min=3,max=100,alphanum"`
-	Password string `json:"password" validate:"required,min=6,max=128"`
+	FullName string         `json:"fullName" validate:"required,min=2,max=255"`
+	Email    string         `json:"email" validate:"omitempty,email,max=255"`
+	Phone    string         `json:"phone" validate:"omitempty,max=20"`
+	Username string         `json:"username" validate:"omitempty,min=3,max=100,alphanum"`
+	Password string         `json:"password" validate:"required,max=128"` // checked by password policy
+	Device   *DeviceSession `json:"device,omitempty" validate:"omitempty"`
+}
+
+// ===== Password Reset =====
+
+type RequestPasswordReset_Payload struct {
+	Identifier string `json:"identifier" validate:"required,min=3,max=255"` // username, email, or phone
+}
+
+type ConfirmPasswordReset_Payload struct {
+	Token       string `json:"token" validate:"required,max=255"`
+	NewPassword string `json:"newPassword" validate:"required,max=128"` // checked by password policy
+}
+
+type ChangePassword_Payload struct {
+	CurrentPassword string `json:"currentPassword" validate:"required,max=128"`
+	NewPassword     string `json:"newPassword" validate:"required,max=128"` // checked by password policy
+}
+
+// ===== Passwordless Login =====
+
+type RequestLoginOtp_Payload struct {
+	Identifier string `json:"identifier" validate:"required,min=3,max=255"` // email or phone
+}
+
+type RequestLoginOtp_Result struct {
+	ExpiredAt int64 `json:"expiredAt"`
+	ResendAt  int64 `json:"resendAt"` // earliest time another code can be requested
+}
+
+type LoginOtp_Payload struct {
+	Identifier string         `json:"identifier" validate:"required,min=3,max=255"` // email or phone
+	Code       string         `json:"code" validate:"required,numeric,len=6"`
+	Device     *DeviceSession `json:"device,omitempty" validate:"omitempty"`
+}
+
+// ===== Identifier Verification =====
+
+type RequestVerification_Payload struct {
+	Identifier string `json:"identifier" validate:"required,min=3,max=255"` // email or phone
+}
+
+type ConfirmVerification_Payload struct {
+	Identifier string `json:"identifier" validate:"required,min=3,max=255"` // email or phone
+	Code       string `json:"code" validate:"required,numeric,len=6"`
 }
 
 // ===== OAuth User Info (from provider) =====
 
 type OAuthUserInfo struct {
-	ProviderId    string `json:"providerId"` // Provider's user ID
-	Email         string `json:"email"`      // User's email from provider
-	Name          string `json:"name"`       // User's name from provider
-	Picture       string `json:"picture"`    // Profile picture URL
-	EmailVerified bool   `json:"emailVerified"`
+	ProviderId     string `json:"providerId"` // Provider's user ID
+	Email          string `json:"email"`      // User's email from provider
+	Name           string `json:"name"`       // User's name from provider
+	Picture        string `json:"picture"`    // Profile picture URL
+	EmailVerified  bool   `json:"emailVerified"`
+	IsPrivateEmail bool   `json:"isPrivateEmail"` // Relay address (e.g. Apple private relay), not the user's real mailbox
 }
@@ -0,0 +1,42 @@
+package dto
+
+type ClientAuth struct {
+	ClientId                      string             `json:"clientId"`
+	Name                          string             `json:"name"`
+	ClientType                    *ClientType_Result `json:"clientType"`
+	TokenLifetime                 int64              `json:"tokenLifetime"`
+	Status                        *Status            `json:"status"`
+	ClientSecretRotatedAt         int64              `json:"clientSecretRotatedAt,omitempty"`
+	PreviousClientSecretExpiredAt int64              `json:"previousClientSecretExpiredAt,omitempty"` // Previous secret is accepted until this time
+	ModifiedBy                    *Subject           `json:"modifiedBy"`
+	CreatedAt                     int64              `json:"createdAt"`
+	UpdatedAt                     int64              `json:"updatedAt"`
+	Version                       int64              `json:"version"`
+}
+
+type ClientType_Result struct {
+	Id   Role_Enum `json:"id"`
+	Name string    `json:"name,omitempty"`
+}
+
+type CreateClientAuth_Payload struct {
+	Name          string    `json:"name" validate:"required,max=255"`
+	ClientTypeId  Role_Enum `json:"clientTypeId" validate:"required,oneof=1 2 5"`        // 1=ANONYMOUS_ADMIN, 2=ANONYMOUS_USER, 5=SERVICE
+	TokenLifetime int64     `json:"tokenLifetime,omitempty" validate:"omitempty,gte=60"` // Seconds, defaults to 30 days
+}
+
+type UpdateClientAuth_Payload struct {
+	Name          string             `json:"name,omitempty" validate:"omitempty,max=255"`
+	TokenLifetime int64              `json:"tokenLifetime,omitempty" validate:"omitempty,gte=60"`
+	StatusId      ControlStatus_Enum `json:"statusId,omitempty" validate:"omitempty,oneof=1 2"` // 1=ACTIVE, 2=INACTIVE
+}
+
+// ClientAuthSecret_Result contains the plain client secret, it is only returned once and can not be retrieved again
+type ClientAuthSecret_Result struct {
+	Client       *ClientAuth `json:"client"`
+	ClientSecret string      `json:"clientSecret"`
+}
+
+type ListClientAuth_Result struct {
+	Clients []*ClientAuth `json:"clients"`
+}
@@ -14,6 +14,9 @@ const (
 	// User
 	Role_ANONYMOUS_USER Role_Enum = 2
 	Role_USER           Role_Enum = 4
+
+	// Machine-to-machine client
+	Role_SERVICE Role_Enum = 5
 )
 
 var (
@@ -23,6 +26,7 @@ var (
 		2: "ANONYMOUS_USER",
 		3: "ADMIN",
 		4: "USER",
+		5: "SERVICE",
 	}
 
 	Role_Enum_value = map[string]int32{
@@ -31,6 +35,7 @@ var (
 		"ANONYMOUS_USER":  2,
 		"ADMIN":           3,
 		"USER":            4,
+		"SERVICE":         5,
 	}
 )
 
@@ -159,6 +164,8 @@ const (
 	AuthProvider_GOOGLE   AuthProvider_Enum = 2
 	AuthProvider_FACEBOOK AuthProvider_Enum = 3
 	AuthProvider_APPLE    AuthProvider_Enum = 4
+	AuthProvider_TOTP     AuthProvider_Enum = 5
+	AuthProvider_OTP      AuthProvider_Enum = 6
 )
 
 var (
@@ -168,6 +175,8 @@ var (
 		2: "GOOGLE",
 		3: "FACEBOOK",
 		4: "APPLE",
+		5: "TOTP",
+		6: "OTP",
 	}
 	AuthProvider_Enum_value = map[string]int32{
 		"UNKNOWN":  0,
@@ -175,5 +184,7 @@ var (
 		"GOOGLE":   2,
 		"FACEBOOK": 3,
 		"APPLE":    4,
+		"TOTP":     5,
+		"OTP":      6,
 	}
 )
@@ -4,3 +4,19 @@ type ValidateJwt_Payload struct {
 	Subject  *string  `json:"subject,omitempty"`
 	Audience []string `json:"audience,omitempty"`
 }
+
+// Jwks is a JSON Web Key Set (RFC 7517) of public keys used to verify issued tokens
+type Jwks struct {
+	Keys []*Jwk `json:"keys"`
+}
+
+type Jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
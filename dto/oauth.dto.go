@@ -0,0 +1,54 @@
+package dto
+
+// ===== OAuth 2.0 Token Endpoint =====
+// Fields follow RFC 6749 naming, so off-the-shelf OAuth client libraries can be used
+
+type OAuthToken_Payload struct {
+	GrantType    string `form:"grant_type"`
+	ClientId     string `form:"client_id"`     // Only when client does not authenticate with Basic auth
+	ClientSecret string `form:"client_secret"` // Only when client does not authenticate with Basic auth
+	Scope        string `form:"scope"`         // Space-delimited privileges, defaults to every privilege of the client
+}
+
+type OAuthToken_Result struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+	Scope       string `json:"scope,omitempty"`
+}
+
+type OAuthError_Result struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
+// ===== OAuth 2.0 Token Introspection (RFC 7662) and Revocation (RFC 7009) =====
+
+type OAuthTokenIntrospection_Payload struct {
+	Token         string `form:"token"`
+	TokenTypeHint string `form:"token_type_hint"` // access_token or refresh_token, optional
+}
+
+// OAuthTokenIntrospection_Result only contains active if the token is not active
+type OAuthTokenIntrospection_Result struct {
+	Active    bool   `json:"active"`
+	Sub       string `json:"sub,omitempty"`
+	Scope     string `json:"scope,omitempty"`
+	Exp       int64  `json:"exp,omitempty"`
+	Iat       int64  `json:"iat,omitempty"`
+	Iss       string `json:"iss,omitempty"`
+	Jti       string `json:"jti,omitempty"`
+	Ent       int32  `json:"ent,omitempty"` // Subject type, see Role_Enum
+	TokenType string `json:"token_type,omitempty"`
+	// Admin acting as the subject of an impersonation token, RFC 8693 section 4.1
+	Act *OAuthTokenActor `json:"act,omitempty"`
+}
+
+type OAuthTokenActor struct {
+	Sub string `json:"sub"`
+}
+
+type OAuthTokenRevocation_Payload struct {
+	Token         string `form:"token"`
+	TokenTypeHint string `form:"token_type_hint"` // access_token or refresh_token, optional
+}
@@ -0,0 +1,11 @@
+package dto
+
+type SecurityEvent struct {
+	Type       string `json:"type"`
+	SubjectId  string `json:"subjectId,omitempty"`
+	SessionXid string `json:"sessionXid,omitempty"`
+	FamilyId   string `json:"familyId,omitempty"`
+	DeviceId   string `json:"deviceId,omitempty"`
+	ClientIp   string `json:"clientIp,omitempty"`
+	OccurredAt int64  `json:"occurredAt"`
+}
@@ -11,6 +11,9 @@ type CreateUserSession_Result_Data struct {
 	AccessSession  *Session `json:"accessSession"`
 	RefreshSession *Session `json:"refreshSession"`
 	AccessScopes   []string `json:"accessScopes"`
+
+	// Set instead of sessions when login requires a second factor
+	TwoFactorChallenge *TwoFactorChallenge `json:"twoFactorChallenge,omitempty"`
 }
 
 type Session struct {
@@ -30,3 +33,35 @@ type DeviceSession struct {
 	NotificationChannelId NotificationChannel_Enum `json:"notificationChannelId,omitempty"`
 	NotificationToken     string                   `json:"notificationChannelToken,omitempty" validate:"omitempty,max=512"`
 }
+
+type DevicePlatform_Result struct {
+	Id   DevicePlatform_Enum `json:"id"`
+	Name string              `json:"name,omitempty"`
+}
+
+type ActiveSession struct {
+	Xid             string                 `json:"xid"`
+	DevicePlatform  *DevicePlatform_Result `json:"devicePlatform"`
+	DeviceId        string                 `json:"deviceId,omitempty"`
+	ClientIp        string                 `json:"clientIp,omitempty"`
+	AuthProviderId  AuthProvider_Enum      `json:"authProviderId"`
+	CreatedAt       int64                  `json:"createdAt"`
+	ExpiredAt       int64                  `json:"expiredAt"`
+	IsCurrent       bool                   `json:"isCurrent"`
+	IsImpersonation bool                   `json:"isImpersonation"`
+}
+
+type ListActiveSession_Result struct {
+	Sessions []*ActiveSession `json:"sessions"`
+}
+
+type StartImpersonation_Payload struct {
+	Reason string `json:"reason" validate:"required,max=500"`
+}
+
+// StartImpersonation_Result has no refresh session, impersonation sessions can not be refreshed
+type StartImpersonation_Result struct {
+	User          *User    `json:"user"`
+	AccessSession *Session `json:"accessSession"`
+	AccessScopes  []string `json:"accessScopes"`
+}
@@ -0,0 +1,24 @@
+package dto
+
+type EnrollTotp_Result struct {
+	Secret string `json:"secret"`
+	Uri    string `json:"uri"` // otpauth:// URI, usually rendered as QR code
+}
+
+type TwoFactorCode_Payload struct {
+	Code string `json:"code" validate:"required,min=6,max=64"` // TOTP or recovery code
+}
+
+type RecoveryCodes_Result struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+type TwoFactorChallenge struct {
+	Token     string `json:"token"`
+	ExpiredAt int64  `json:"expiredAt"`
+}
+
+type LoginTwoFactor_Payload struct {
+	ChallengeToken string `json:"challengeToken" validate:"required,max=255"`
+	Code           string `json:"code" validate:"required,min=6,max=64"` // TOTP or recovery code
+}
@@ -33,3 +33,80 @@ var InvalidCredentials = b.NewError("E_AUTH_1", "Invalid credentials",
 var InvalidClientType = b.NewError("E_AUTH_2", "Invalid client type",
 	errk.WithHTTPStatus(fhttp.StatusUnauthorized),
 )
+
+var UnsupportedAuthProvider = b.NewError("E_AUTH_4", "Auth provider is not supported",
+	errk.WithHTTPStatus(fhttp.StatusBadRequest),
+)
+
+var InvalidPasswordResetToken = b.NewError("E_AUTH_5", "Password reset token is invalid or has expired",
+	errk.WithHTTPStatus(fhttp.StatusBadRequest),
+)
+
+var IncorrectPassword = b.NewError("E_AUTH_6", "Current password is incorrect",
+	errk.WithHTTPStatus(fhttp.StatusBadRequest),
+)
+
+var TwoFactorAlreadyEnabled = b.NewError("E_AUTH_7", "Two-factor authentication is already enabled",
+	errk.WithHTTPStatus(fhttp.StatusConflict),
+)
+
+var TwoFactorNotEnabled = b.NewError("E_AUTH_8", "Two-factor authentication is not enabled",
+	errk.WithHTTPStatus(fhttp.StatusBadRequest),
+)
+
+var InvalidTwoFactorCode = b.NewError("E_AUTH_9", "Two-factor code is invalid",
+	errk.WithHTTPStatus(fhttp.StatusBadRequest),
+)
+
+var InvalidTwoFactorChallenge = b.NewError("E_AUTH_10", "Two-factor challenge is invalid or has expired",
+	errk.WithHTTPStatus(fhttp.StatusUnauthorized),
+)
+
+var TooManyOtpRequests = b.NewError("E_AUTH_11", "Too many one-time code requests, try again later",
+	errk.WithHTTPStatus(fhttp.StatusTooManyRequests),
+)
+
+var InvalidOtpCode = b.NewError("E_AUTH_12", "One-time code is invalid or has expired",
+	errk.WithHTTPStatus(fhttp.StatusUnauthorized),
+)
+
+var InactiveClient = b.NewError("E_AUTH_13", "Client is inactive",
+	errk.WithHTTPStatus(fhttp.StatusForbidden),
+)
+
+var ImpersonationNotAllowed = b.NewError("E_AUTH_14", "Action is not allowed while impersonating a user",
+	errk.WithHTTPStatus(fhttp.StatusForbidden),
+)
+
+var TooManyTwoFactorAttempts = b.NewError("E_AUTH_15", "Too many invalid two-factor codes, try again later",
+	errk.WithHTTPStatus(fhttp.StatusTooManyRequests),
+)
+
+// User Errors
+var IdentifierAlreadyRegistered = b.NewError("E_USER_1", "Identifier is already registered",
+	errk.WithHTTPStatus(fhttp.StatusConflict),
+)
+
+var IdentifierRequired = b.NewError("E_USER_2", "Email, phone or username is required",
+	errk.WithHTTPStatus(fhttp.StatusUnprocessableEntity),
+)
+
+var IdentifierNotVerified = b.NewError("E_USER_3", "Email or phone has not been verified",
+	errk.WithHTTPStatus(fhttp.StatusForbidden),
+)
+
+var InvalidVerificationCode = b.NewError("E_USER_4", "Verification code is invalid or has expired",
+	errk.WithHTTPStatus(fhttp.StatusBadRequest),
+)
+
+var IdentityAlreadyLinked = b.NewError("E_USER_5", "Identity is already linked to an account",
+	errk.WithHTTPStatus(fhttp.StatusConflict),
+)
+
+var LastCredential = b.NewError("E_USER_6", "Can not remove the last sign-in method of the account",
+	errk.WithHTTPStatus(fhttp.StatusConflict),
+)
+
+var TooManyVerificationRequests = b.NewError("E_USER_7", "Too many verification code requests, try again later",
+	errk.WithHTTPStatus(fhttp.StatusTooManyRequests),
+)
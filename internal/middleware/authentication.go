@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"errors"
+
+	"github.com/go-konsultin/errk"
+	"github.com/go-konsultin/logk"
+	logkOption "github.com/go-konsultin/logk/option"
+	"github.com/go-konsultin/routek"
+	"github.com/konsultin/project-goes-here/internal/svc-core/pkg/httpk"
+	unaryHttpk "github.com/konsultin/project-goes-here/internal/svc-core/pkg/httpk/unary"
+	"github.com/valyala/fasthttp"
+)
+
+// Authenticator verifies a bearer token and returns the subject it was issued to.
+// A nil subject without error leaves the request unauthenticated
+type Authenticator func(ctx *fasthttp.RequestCtx, token string) (*unaryHttpk.Subject, error)
+
+// Authentication rejects requests with invalid or revoked bearer token, and sets the verified subject of the request
+func Authentication(authenticate Authenticator, log logk.Logger, onError ErrorResponder) func(fasthttp.RequestHandler) fasthttp.RequestHandler {
+	return func(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+		return func(ctx *fasthttp.RequestCtx) {
+			token, _ := ctx.UserValue(httpk.BearerToken).(string)
+			if token == "" {
+				next(ctx)
+				return
+			}
+
+			subject, err := authenticate(ctx, token)
+			if err != nil {
+				log.Warn("request rejected: bearer token is not authenticated", logkOption.Error(err))
+				status, code, message := errorInfo(err)
+				onError(ctx, status, code, message, err)
+				return
+			}
+
+			if subject != nil {
+				ctx.SetUserValue(unaryHttpk.SubjectKey, *subject)
+			}
+
+			next(ctx)
+		}
+	}
+}
+
+// errorInfo extracts HTTP status, code and message from errk.Error the same way handler errors are responded
+func errorInfo(err error) (int, routek.Code, string) {
+	var errkErr *errk.Error
+	if errors.As(err, &errkErr) {
+		status := fasthttp.StatusInternalServerError
+		if s, ok := errkErr.Metadata()["http_status"].(int); ok {
+			status = s
+		}
+		return status, routek.Code(errkErr.Code()), errkErr.Message()
+	}
+	return fasthttp.StatusInternalServerError, routek.CodeInternalError, "internal server error"
+}
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"github.com/go-konsultin/logk"
+	"github.com/konsultin/project-goes-here/internal/svc-core/pkg/httpk"
+	unaryHttpk "github.com/konsultin/project-goes-here/internal/svc-core/pkg/httpk/unary"
+	"github.com/valyala/fasthttp"
+)
+
+// Authorization enforces the route policy against the authenticated subject.
+// Requests without an authenticated subject are rejected with 401, missing privileges with 403
+func Authorization(policies *RoutePolicies, log logk.Logger, onError ErrorResponder) func(fasthttp.RequestHandler) fasthttp.RequestHandler {
+	return func(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+		return func(ctx *fasthttp.RequestCtx) {
+			policy := policies.Match(string(ctx.Method()), string(ctx.Path()))
+			if policy == nil || policy.Anonymous {
+				next(ctx)
+				return
+			}
+
+			subject, ok := ctx.UserValue(unaryHttpk.SubjectKey).(unaryHttpk.Subject)
+			if !ok {
+				log.Warnf("request rejected: route requires authentication. Method=%s Path=%s", policy.Method, policy.Path)
+				status, code, message := errorInfo(httpk.UnauthorizedError)
+				onError(ctx, status, code, message, nil)
+				return
+			}
+
+			if subject.Restricted && !policy.RequiresAny(subject.Privileges) {
+				log.Warnf("request rejected: restricted subject is not allowed on route. SubjectId=%s Path=%s", subject.Id, policy.Path)
+				status, code, message := errorInfo(httpk.UnauthorizedError)
+				onError(ctx, status, code, message, nil)
+				return
+			}
+
+			for _, privilege := range policy.Privileges {
+				if !subject.HasPrivilege(privilege) {
+					log.Warnf("request rejected: subject is not granted with privilege. SubjectId=%s Privilege=%s Path=%s",
+						subject.Id, privilege, policy.Path)
+					status, code, message := errorInfo(httpk.ForbiddenError)
+					onError(ctx, status, code, message, nil)
+					return
+				}
+			}
+
+			next(ctx)
+		}
+	}
+}
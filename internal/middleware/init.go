@@ -25,14 +25,22 @@ func Init(cfg Config) (handler func(ctx *fasthttp.RequestCtx), err error) {
 		metrics = NewMetrics()
 	}
 
-	handler = Chain(cfg.Handler,
+	middlewares := []func(fasthttp.RequestHandler) fasthttp.RequestHandler{
 		Recovery(cfg.Logger, cfg.OnError),
 		RequestID(),
 		Logging(cfg.Logger, metrics),
 		RateLimit(rl, cfg.Logger, cfg.OnError),
 		CORS(cfg.CORSAllowOrigins),
 		unaryHttpk.AuthorizationMiddleware,
-	)
+	}
+	if cfg.Authenticator != nil {
+		middlewares = append(middlewares, Authentication(cfg.Authenticator, cfg.Logger, cfg.OnError))
+	}
+	if cfg.RoutePolicies != nil {
+		middlewares = append(middlewares, Authorization(cfg.RoutePolicies, cfg.Logger, cfg.OnError))
+	}
+
+	handler = Chain(cfg.Handler, middlewares...)
 
 	return handler, nil
 }
@@ -0,0 +1,179 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RoutePolicy is the access requirement of a route declared in api-route.yaml
+type RoutePolicy struct {
+	Method     string
+	Path       string
+	Anonymous  bool
+	Privileges []string
+	segments   []string
+}
+
+// RoutePolicies matches request to the access requirement of its route
+type RoutePolicies struct {
+	routes []*RoutePolicy
+}
+
+type (
+	routePolicyDocument map[string]struct {
+		Routes []*RoutePolicy `yaml:"route"`
+	}
+)
+
+// RequiresAny checks if the route requires one of the privileges
+func (p *RoutePolicy) RequiresAny(privileges []string) bool {
+	for _, required := range p.Privileges {
+		for _, privilege := range privileges {
+			if required == privilege {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (p *RoutePolicy) UnmarshalYAML(value *yaml.Node) error {
+	var raw map[string]any
+	if err := value.Decode(&raw); err != nil {
+		return err
+	}
+
+	for key, val := range raw {
+		lowerKey := strings.ToLower(key)
+		switch lowerKey {
+		case "get", "post", "put", "delete", "patch", "head", "options":
+			path, ok := val.(string)
+			if !ok {
+				return fmt.Errorf("route %q must map to a path string", key)
+			}
+			p.Method = strings.ToUpper(lowerKey)
+			p.Path = path
+		case "anonymous":
+			anonymous, ok := val.(bool)
+			if !ok {
+				return fmt.Errorf("route %s anonymous must be a boolean", p.Path)
+			}
+			p.Anonymous = anonymous
+		case "privileges":
+			privileges, ok := val.([]any)
+			if !ok {
+				return fmt.Errorf("route %s privileges must be a list", p.Path)
+			}
+			for _, privilege := range privileges {
+				xid, ok := privilege.(string)
+				if !ok || xid == "" {
+					return fmt.Errorf("route %s privileges must be a list of privilege xid", p.Path)
+				}
+				p.Privileges = append(p.Privileges, xid)
+			}
+		}
+	}
+
+	if p.Method == "" || p.Path == "" {
+		return errors.New("route does not declare an HTTP method and path")
+	}
+
+	if p.Anonymous && len(p.Privileges) > 0 {
+		return fmt.Errorf("route %s %s allows anonymous access but requires privileges", p.Method, p.Path)
+	}
+
+	p.segments = splitPath(p.Path)
+
+	return nil
+}
+
+// LoadRoutePolicies reads access requirement of every route in the route file
+func LoadRoutePolicies(routeFile string) (*RoutePolicies, error) {
+	content, err := os.ReadFile(routeFile)
+	if err != nil {
+		return nil, fmt.Errorf("middleware: read %s: %w", routeFile, err)
+	}
+
+	var doc routePolicyDocument
+	if err = yaml.Unmarshal(content, &doc); err != nil {
+		return nil, fmt.Errorf("middleware: parse %s: %w", routeFile, err)
+	}
+
+	policies := new(RoutePolicies)
+	for _, group := range doc {
+		policies.routes = append(policies.routes, group.Routes...)
+	}
+
+	return policies, nil
+}
+
+// Match returns the policy of the route handling the request, nil if no route matches
+func (r *RoutePolicies) Match(method, path string) *RoutePolicy {
+	segments := splitPath(path)
+
+	var matched *RoutePolicy
+	matchedParams := 0
+	for _, route := range r.routes {
+		if route.Method != method {
+			continue
+		}
+
+		params, ok := matchSegments(route.segments, segments)
+		if !ok {
+			continue
+		}
+
+		// Static segments take precedence over path parameters
+		if matched == nil || params < matchedParams {
+			matched = route
+			matchedParams = params
+		}
+	}
+
+	return matched
+}
+
+// Privileges returns every privilege xid required by routes
+func (r *RoutePolicies) Privileges() []string {
+	set := make(map[string]struct{})
+	for _, route := range r.routes {
+		for _, privilege := range route.Privileges {
+			set[privilege] = struct{}{}
+		}
+	}
+
+	privileges := make([]string, 0, len(set))
+	for privilege := range set {
+		privileges = append(privileges, privilege)
+	}
+	sort.Strings(privileges)
+
+	return privileges
+}
+
+func splitPath(path string) []string {
+	return strings.Split(strings.Trim(path, "/"), "/")
+}
+
+func matchSegments(route, path []string) (params int, ok bool) {
+	if len(route) != len(path) {
+		return 0, false
+	}
+
+	for i, segment := range route {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			params++
+			continue
+		}
+		if segment != path[i] {
+			return 0, false
+		}
+	}
+
+	return params, true
+}
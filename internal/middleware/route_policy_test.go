@@ -0,0 +1,161 @@
+package middleware
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+const testRouteFile = `
+core:
+  route:
+    - get: /
+      handler: HealthCheck
+      anonymous: true
+    - put: /v1/users/sessions
+      handler: HandleUserRefreshToken
+      privileges: [refresh_user_token]
+    - get: /v1/users/{xid}
+      handler: HandleGetUser
+      privileges: [read_user]
+    - get: /v1/users/me
+      handler: HandleGetMe
+    - delete: /v1/users/{xid}/sessions/{sessionXid}
+      handler: HandleRevokeUserSession
+      privileges: [revoke_user_session, read_user]
+admin:
+  route:
+    - get: /v1/admin/clients
+      handler: HandleListClientAuth
+      privileges: [manage_client_auth]
+`
+
+func writeRouteFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "api-route.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("write route file: %v", err)
+	}
+	return path
+}
+
+func TestRoutePoliciesMatch(t *testing.T) {
+	policies, err := LoadRoutePolicies(writeRouteFile(t, testRouteFile))
+	if err != nil {
+		t.Fatalf("LoadRoutePolicies() error = %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		method    string
+		path      string
+		wantPath  string
+		anonymous bool
+	}{
+		{name: "root", method: "GET", path: "/", wantPath: "/", anonymous: true},
+		{name: "static route", method: "PUT", path: "/v1/users/sessions", wantPath: "/v1/users/sessions"},
+		{name: "trailing slash", method: "PUT", path: "/v1/users/sessions/", wantPath: "/v1/users/sessions"},
+		{name: "path parameter", method: "GET", path: "/v1/users/abc123", wantPath: "/v1/users/{xid}"},
+		{name: "static segment over parameter", method: "GET", path: "/v1/users/me", wantPath: "/v1/users/me"},
+		{name: "many path parameters", method: "DELETE", path: "/v1/users/abc/sessions/def", wantPath: "/v1/users/{xid}/sessions/{sessionXid}"},
+		{name: "route of another group", method: "GET", path: "/v1/admin/clients", wantPath: "/v1/admin/clients"},
+		{name: "other method", method: "POST", path: "/v1/users/sessions"},
+		{name: "fewer segments", method: "DELETE", path: "/v1/users/abc/sessions"},
+		{name: "more segments", method: "GET", path: "/v1/users/abc/def"},
+		{name: "unknown path", method: "GET", path: "/v1/unknown"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := policies.Match(tt.method, tt.path)
+			if tt.wantPath == "" {
+				if got != nil {
+					t.Errorf("Match() = %s %s, want nil", got.Method, got.Path)
+				}
+				return
+			}
+			if got == nil {
+				t.Fatalf("Match() = nil, want %s", tt.wantPath)
+			}
+			if got.Path != tt.wantPath || got.Anonymous != tt.anonymous {
+				t.Errorf("Match() = %s anonymous=%t, want %s anonymous=%t", got.Path, got.Anonymous, tt.wantPath, tt.anonymous)
+			}
+		})
+	}
+}
+
+func TestRoutePoliciesPrivileges(t *testing.T) {
+	policies, err := LoadRoutePolicies(writeRouteFile(t, testRouteFile))
+	if err != nil {
+		t.Fatalf("LoadRoutePolicies() error = %v", err)
+	}
+
+	want := []string{"manage_client_auth", "read_user", "refresh_user_token", "revoke_user_session"}
+	if got := policies.Privileges(); !slices.Equal(got, want) {
+		t.Errorf("Privileges() = %v, want %v", got, want)
+	}
+}
+
+func TestRoutePolicyRequiresAny(t *testing.T) {
+	policy := &RoutePolicy{Privileges: []string{"revoke_user_session", "read_user"}}
+
+	tests := []struct {
+		name       string
+		policy     *RoutePolicy
+		privileges []string
+		want       bool
+	}{
+		{name: "one of required", policy: policy, privileges: []string{"refresh_user_token", "read_user"}, want: true},
+		{name: "none of required", policy: policy, privileges: []string{"refresh_user_token"}},
+		{name: "no privileges", policy: policy},
+		{name: "route without privileges", policy: &RoutePolicy{}, privileges: []string{"read_user"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.RequiresAny(tt.privileges); got != tt.want {
+				t.Errorf("RequiresAny() = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadRoutePoliciesInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{name: "missing method", content: "core:\n  route:\n    - handler: HealthCheck\n"},
+		{name: "path is not a string", content: "core:\n  route:\n    - get: [a]\n"},
+		{name: "anonymous is not a boolean", content: "core:\n  route:\n    - get: /\n      anonymous: yes please\n"},
+		{name: "privileges is not a list", content: "core:\n  route:\n    - get: /\n      privileges: read_user\n"},
+		{name: "empty privilege", content: "core:\n  route:\n    - get: /\n      privileges: ['']\n"},
+		{name: "anonymous with privileges", content: "core:\n  route:\n    - get: /\n      anonymous: true\n      privileges: [read_user]\n"},
+		{name: "malformed yaml", content: "core: [\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := LoadRoutePolicies(writeRouteFile(t, tt.content)); err == nil {
+				t.Errorf("LoadRoutePolicies() error = nil, want error")
+			}
+		})
+	}
+
+	if _, err := LoadRoutePolicies(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Errorf("LoadRoutePolicies() of missing file error = nil, want error")
+	}
+}
+
+func TestLoadRoutePoliciesOfApi(t *testing.T) {
+	policies, err := LoadRoutePolicies("../api-route.yaml")
+	if err != nil {
+		t.Fatalf("LoadRoutePolicies() error = %v", err)
+	}
+
+	policy := policies.Match("PUT", "/v1/users/sessions")
+	if policy == nil || !slices.Equal(policy.Privileges, []string{"refresh_user_token"}) {
+		t.Errorf("Match() of refresh route = %+v, want route requiring refresh_user_token", policy)
+	}
+}
@@ -16,4 +16,6 @@ type Config struct {
 	RateLimitBurst   int
 	CORSAllowOrigins []string
 	Metrics          *Metrics
+	Authenticator    Authenticator
+	RoutePolicies    *RoutePolicies
 }
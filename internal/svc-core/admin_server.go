@@ -0,0 +1,250 @@
+package svcCore
+
+import (
+	"github.com/konsultin/project-goes-here/dto"
+	httpkPkg "github.com/konsultin/project-goes-here/internal/svc-core/pkg/httpk"
+	f "github.com/valyala/fasthttp"
+)
+
+// HandleRevokeUserSessions handles revocation of every session of a user by admin
+// @Summary      Revoke user sessions
+// @Description  Delete every session of the given user, logging the user out from all devices
+// @Tags         admin
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        xid  path  string  true  "User Xid"
+// @Success      200  {object}  dto.Response[dto.Empty]
+// @Failure      401  {object}  dto.Response[dto.Empty] "Unauthorized"
+// @Failure      403  {object}  dto.Response[dto.Empty] "Forbidden"
+// @Failure      404  {object}  dto.Response[dto.Empty] "Not Found"
+// @Failure      500  {object}  dto.Response[dto.Empty] "Internal Error"
+// @Router       /v1/admin/users/{xid}/sessions [delete]
+func (s *Server) HandleRevokeUserSessions(ctx *f.RequestCtx) (*dto.Empty, error) {
+	userXid, _ := ctx.UserValue("xid").(string)
+
+	// Init Service
+	svc, err := s.NewService(ctx)
+	if err != nil {
+		s.log.Errorf("Failed to create service: %v", err)
+		return nil, err
+	}
+	defer svc.Close()
+
+	err = svc.RevokeUserSessions(userXid)
+	if err != nil {
+		return nil, s.wrapError(ctx, err)
+	}
+
+	return &dto.Empty{}, nil
+}
+
+// HandleStartImpersonation handles creation of an impersonation session of a user by admin
+// @Summary      Start impersonation
+// @Description  Create a short-lived session of the given user for current admin, without refresh token. The token carries the admin in act claim, logout ends the impersonation
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        xid      path  string                          true  "User Xid"
+// @Param        request  body  dto.StartImpersonation_Payload  true  "Start Impersonation Payload"
+// @Success      200  {object}  dto.Response[dto.StartImpersonation_Result]
+// @Failure      401  {object}  dto.Response[dto.Empty] "Unauthorized"
+// @Failure      403  {object}  dto.Response[dto.Empty] "Forbidden"
+// @Failure      404  {object}  dto.Response[dto.Empty] "Not Found"
+// @Failure      422  {object}  dto.Response[dto.Empty] "Invalid Payload"
+// @Failure      500  {object}  dto.Response[dto.Empty] "Internal Error"
+// @Router       /v1/admin/users/{xid}/impersonation [post]
+func (s *Server) HandleStartImpersonation(ctx *f.RequestCtx) (*dto.StartImpersonation_Result, error) {
+	userXid, _ := ctx.UserValue("xid").(string)
+
+	// Bind and validate request payload
+	payload, err := httpkPkg.BindAndValidate[dto.StartImpersonation_Payload](ctx)
+	if err != nil {
+		return nil, s.wrapError(ctx, err)
+	}
+
+	// Init Service
+	svc, err := s.NewService(ctx)
+	if err != nil {
+		s.log.Errorf("Failed to create service: %v", err)
+		return nil, err
+	}
+	defer svc.Close()
+
+	result, err := svc.StartImpersonation(userXid, payload)
+	if err != nil {
+		return nil, s.wrapError(ctx, err)
+	}
+
+	return result, nil
+}
+
+// HandleCreateClientAuth handles creation of client credentials by admin
+// @Summary      Create client
+// @Description  Create client credentials with a generated client id and secret. The secret is only returned once
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        request body dto.CreateClientAuth_Payload true "Create Client Payload"
+// @Success      200  {object}  dto.Response[dto.ClientAuthSecret_Result]
+// @Failure      401  {object}  dto.Response[dto.Empty] "Unauthorized"
+// @Failure      403  {object}  dto.Response[dto.Empty] "Forbidden"
+// @Failure      422  {object}  dto.Response[dto.Empty] "Invalid Payload"
+// @Failure      500  {object}  dto.Response[dto.Empty] "Internal Error"
+// @Router       /v1/admin/clients [post]
+func (s *Server) HandleCreateClientAuth(ctx *f.RequestCtx) (*dto.ClientAuthSecret_Result, error) {
+	// Bind and validate request payload
+	payload, err := httpkPkg.BindAndValidate[dto.CreateClientAuth_Payload](ctx)
+	if err != nil {
+		return nil, s.wrapError(ctx, err)
+	}
+
+	// Init Service
+	svc, err := s.NewService(ctx)
+	if err != nil {
+		s.log.Errorf("Failed to create service: %v", err)
+		return nil, err
+	}
+	defer svc.Close()
+
+	result, err := svc.CreateClientAuth(payload)
+	if err != nil {
+		return nil, s.wrapError(ctx, err)
+	}
+
+	return result, nil
+}
+
+// HandleListClientAuths handles listing of client credentials by admin
+// @Summary      List clients
+// @Description  Get every client, secrets are not included
+// @Tags         admin
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Success      200  {object}  dto.Response[dto.ListClientAuth_Result]
+// @Failure      401  {object}  dto.Response[dto.Empty] "Unauthorized"
+// @Failure      403  {object}  dto.Response[dto.Empty] "Forbidden"
+// @Failure      500  {object}  dto.Response[dto.Empty] "Internal Error"
+// @Router       /v1/admin/clients [get]
+func (s *Server) HandleListClientAuths(ctx *f.RequestCtx) (*dto.ListClientAuth_Result, error) {
+	// Init Service
+	svc, err := s.NewService(ctx)
+	if err != nil {
+		s.log.Errorf("Failed to create service: %v", err)
+		return nil, err
+	}
+	defer svc.Close()
+
+	result, err := svc.ListClientAuths()
+	if err != nil {
+		return nil, s.wrapError(ctx, err)
+	}
+
+	return result, nil
+}
+
+// HandleUpdateClientAuth handles update of client credentials by admin
+// @Summary      Update client
+// @Description  Update name, token lifetime or status of a client, only fields set are changed
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        clientId  path  string  true  "Client Id"
+// @Param        request body dto.UpdateClientAuth_Payload true "Update Client Payload"
+// @Success      200  {object}  dto.Response[dto.ClientAuth]
+// @Failure      401  {object}  dto.Response[dto.Empty] "Unauthorized"
+// @Failure      403  {object}  dto.Response[dto.Empty] "Forbidden"
+// @Failure      404  {object}  dto.Response[dto.Empty] "Not Found"
+// @Failure      422  {object}  dto.Response[dto.Empty] "Invalid Payload"
+// @Failure      500  {object}  dto.Response[dto.Empty] "Internal Error"
+// @Router       /v1/admin/clients/{clientId} [put]
+func (s *Server) HandleUpdateClientAuth(ctx *f.RequestCtx) (*dto.ClientAuth, error) {
+	clientId, _ := ctx.UserValue("clientId").(string)
+
+	// Bind and validate request payload
+	payload, err := httpkPkg.BindAndValidate[dto.UpdateClientAuth_Payload](ctx)
+	if err != nil {
+		return nil, s.wrapError(ctx, err)
+	}
+
+	// Init Service
+	svc, err := s.NewService(ctx)
+	if err != nil {
+		s.log.Errorf("Failed to create service: %v", err)
+		return nil, err
+	}
+	defer svc.Close()
+
+	result, err := svc.UpdateClientAuth(clientId, payload)
+	if err != nil {
+		return nil, s.wrapError(ctx, err)
+	}
+
+	return result, nil
+}
+
+// HandleDeactivateClientAuth handles deactivation of client credentials by admin
+// @Summary      Deactivate client
+// @Description  Stop a client from creating sessions and reject tokens already issued to it
+// @Tags         admin
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        clientId  path  string  true  "Client Id"
+// @Success      200  {object}  dto.Response[dto.Empty]
+// @Failure      401  {object}  dto.Response[dto.Empty] "Unauthorized"
+// @Failure      403  {object}  dto.Response[dto.Empty] "Forbidden"
+// @Failure      404  {object}  dto.Response[dto.Empty] "Not Found"
+// @Failure      500  {object}  dto.Response[dto.Empty] "Internal Error"
+// @Router       /v1/admin/clients/{clientId} [delete]
+func (s *Server) HandleDeactivateClientAuth(ctx *f.RequestCtx) (*dto.Empty, error) {
+	clientId, _ := ctx.UserValue("clientId").(string)
+
+	// Init Service
+	svc, err := s.NewService(ctx)
+	if err != nil {
+		s.log.Errorf("Failed to create service: %v", err)
+		return nil, err
+	}
+	defer svc.Close()
+
+	err = svc.DeactivateClientAuth(clientId)
+	if err != nil {
+		return nil, s.wrapError(ctx, err)
+	}
+
+	return &dto.Empty{}, nil
+}
+
+// HandleRotateClientSecret handles rotation of a client secret by admin
+// @Summary      Rotate client secret
+// @Description  Generate a new client secret, returned only once. The previous secret is accepted during the rotation overlap
+// @Tags         admin
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        clientId  path  string  true  "Client Id"
+// @Success      200  {object}  dto.Response[dto.ClientAuthSecret_Result]
+// @Failure      401  {object}  dto.Response[dto.Empty] "Unauthorized"
+// @Failure      403  {object}  dto.Response[dto.Empty] "Forbidden"
+// @Failure      404  {object}  dto.Response[dto.Empty] "Not Found"
+// @Failure      500  {object}  dto.Response[dto.Empty] "Internal Error"
+// @Router       /v1/admin/clients/{clientId}/secret [post]
+func (s *Server) HandleRotateClientSecret(ctx *f.RequestCtx) (*dto.ClientAuthSecret_Result, error) {
+	clientId, _ := ctx.UserValue("clientId").(string)
+
+	// Init Service
+	svc, err := s.NewService(ctx)
+	if err != nil {
+		s.log.Errorf("Failed to create service: %v", err)
+		return nil, err
+	}
+	defer svc.Close()
+
+	result, err := svc.RotateClientSecret(clientId)
+	if err != nil {
+		return nil, s.wrapError(ctx, err)
+	}
+
+	return result, nil
+}
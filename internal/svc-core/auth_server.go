@@ -0,0 +1,60 @@
+package svcCore
+
+import (
+	"context"
+
+	"github.com/go-konsultin/errk"
+	"github.com/go-konsultin/logk"
+	logkOption "github.com/go-konsultin/logk/option"
+	"github.com/konsultin/project-goes-here/internal/svc-core/constant"
+	unaryHttpk "github.com/konsultin/project-goes-here/internal/svc-core/pkg/httpk/unary"
+	f "github.com/valyala/fasthttp"
+)
+
+// Authenticate verifies the bearer token of a request and returns its subject, used by authentication middleware
+func (s *Server) Authenticate(ctx *f.RequestCtx, token string) (*unaryHttpk.Subject, error) {
+	// Session lookup only needs redis, db connection is not acquired
+	svc := s.svc.
+		WithContext(ctx).
+		WithLog(logk.Get().NewChild(logkOption.WithNamespace(constant.ServiceName+"/auth"), logkOption.Context(ctx)))
+
+	subject, privileges, err := svc.AuthenticateBearerToken(token)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &unaryHttpk.Subject{
+		Id:         subject.Id,
+		FullName:   subject.FullName,
+		Role:       subject.Role,
+		Privileges: privileges,
+		// Refresh tokens are only accepted on the refresh session route
+		Restricted: len(privileges) == 1 && privileges[0] == constant.PrivilegeRefreshUserToken,
+	}
+	if subject.Actor != nil {
+		result.Actor = &unaryHttpk.Subject{
+			Id:       subject.Actor.Id,
+			FullName: subject.Actor.FullName,
+			Role:     subject.Actor.Role,
+		}
+	}
+
+	return result, nil
+}
+
+// ValidateRoutePrivileges checks that privileges required by routes are registered, to fail fast on startup
+func (s *Server) ValidateRoutePrivileges(xids []string) error {
+	ctx := context.Background()
+
+	rc, err := s.repo.Connect(ctx)
+	if err != nil {
+		return errk.Trace(err)
+	}
+
+	svc := s.svc.
+		WithContext(ctx).
+		WithRepo(rc).
+		WithLog(logk.Get().NewChild(logkOption.WithNamespace(constant.ServiceName + "/auth")))
+
+	return svc.ValidatePrivilegeXids(xids)
+}
@@ -13,8 +13,15 @@ import (
 	"github.com/konsultin/project-goes-here/config"
 	"github.com/konsultin/project-goes-here/internal/svc-core/constant"
 	"github.com/konsultin/project-goes-here/internal/svc-core/model"
+	"github.com/konsultin/project-goes-here/internal/svc-core/pkg/hasher"
 	"github.com/konsultin/project-goes-here/internal/svc-core/pkg/httpk"
 	unaryHttpk "github.com/konsultin/project-goes-here/internal/svc-core/pkg/httpk/unary"
+	"github.com/konsultin/project-goes-here/internal/svc-core/pkg/oauth"
+	"github.com/konsultin/project-goes-here/internal/svc-core/pkg/oauth/apple"
+	"github.com/konsultin/project-goes-here/internal/svc-core/pkg/oauth/facebook"
+	"github.com/konsultin/project-goes-here/internal/svc-core/pkg/oauth/google"
+	"github.com/konsultin/project-goes-here/internal/svc-core/pkg/pwpolicy"
+	"github.com/konsultin/project-goes-here/internal/svc-core/pkg/sender"
 	"github.com/konsultin/project-goes-here/internal/svc-core/repository"
 	"github.com/konsultin/project-goes-here/internal/svc-core/service"
 	f "github.com/valyala/fasthttp"
@@ -40,7 +47,23 @@ func New(config *config.Config, startedAt time.Time) (*Server, error) {
 		return nil, errk.Trace(err)
 	}
 
-	svc := service.NewService(repo, config)
+	jwtKeyring, err := service.NewJwtKeyring(config)
+	if err != nil {
+		return nil, errk.Trace(err)
+	}
+
+	passwordPolicy, err := newPasswordPolicy(config)
+	if err != nil {
+		return nil, errk.Trace(err)
+	}
+
+	svc := service.NewService(repo, config).
+		WithOAuthProviders(newOAuthRegistry(config)).
+		WithJwtKeyring(jwtKeyring).
+		WithSender(newMessageSender(config)).
+		WithHasher(newPasswordHasher(config)).
+		WithClientSecretHasher(newClientSecretHasher(config)).
+		WithPasswordPolicy(passwordPolicy)
 
 	server := &Server{
 		config:    config,
@@ -55,14 +78,128 @@ func New(config *config.Config, startedAt time.Time) (*Server, error) {
 
 }
 
+// newOAuthRegistry registers identity providers that are configured
+func newOAuthRegistry(config *config.Config) *oauth.Registry {
+	registry := oauth.NewRegistry()
+
+	if config.GoogleClientID != "" {
+		registry.Register(google.NewProvider(config.GoogleClientID).WithHostedDomains(config.GoogleHostedDomains))
+	}
+	if config.FacebookAppID != "" && config.FacebookAppSecret != "" {
+		registry.Register(facebook.NewProvider(config.FacebookAppID, config.FacebookAppSecret))
+	}
+	if config.AppleClientID != "" {
+		registry.Register(apple.NewProvider(config.AppleClientID))
+	}
+
+	logk.Get().Infof("OAuth providers registered: %v", registry.Names())
+
+	return registry
+}
+
+// newMessageSender creates the sender delivering messages to users
+func newMessageSender(config *config.Config) sender.Sender {
+	senders := make(map[string]sender.Sender)
+	get := func(name string) sender.Sender {
+		if s, ok := senders[name]; ok {
+			return s
+		}
+
+		var s sender.Sender
+		switch name {
+		case "file":
+			s = sender.NewFileSender(config.MessageSenderFile)
+		case "memory":
+			s = sender.NewMemorySender()
+		default:
+			s = sender.NewLogSender(logk.Get().NewChild(logkOption.WithNamespace(constant.ServiceName + "/sender")))
+		}
+		senders[name] = s
+		return s
+	}
+
+	// Route channels with their own sender
+	var messageSender sender.Sender = get(config.MessageSender)
+	if config.MessageSenderEmail != "" || config.MessageSenderSms != "" {
+		router := sender.NewRouter(messageSender)
+		if config.MessageSenderEmail != "" {
+			router.WithChannel(sender.ChannelEmail, get(config.MessageSenderEmail))
+		}
+		if config.MessageSenderSms != "" {
+			router.WithChannel(sender.ChannelSms, get(config.MessageSenderSms))
+		}
+		messageSender = router
+	}
+
+	logk.Get().Infof("Message sender: %s", messageSender.GetSenderName())
+
+	return messageSender
+}
+
+// newPasswordHasher creates the policy hashing passwords with the configured algorithm, verifying the other one
+func newPasswordHasher(config *config.Config) *hasher.Policy {
+	argon2id := newArgon2idHasher(config)
+	bcrypt := hasher.NewBcrypt(config.BcryptCost)
+
+	if config.PasswordHashAlgorithm == "bcrypt" {
+		return hasher.NewPolicy(bcrypt, argon2id)
+	}
+	return hasher.NewPolicy(argon2id, bcrypt)
+}
+
+// newClientSecretHasher creates the policy hashing client secrets with bcrypt. Argon2id hashes of older secrets are
+// still verified, and upgraded once the client authenticates
+func newClientSecretHasher(config *config.Config) *hasher.Policy {
+	return hasher.NewPolicy(hasher.NewBcrypt(config.BcryptCost), newArgon2idHasher(config))
+}
+
+// newArgon2idHasher creates the argon2id hasher with the configured parameters
+func newArgon2idHasher(config *config.Config) *hasher.Argon2id {
+	return hasher.NewArgon2id(hasher.Argon2idParams{
+		Memory:      config.Argon2Memory,
+		Iterations:  config.Argon2Iterations,
+		Parallelism: config.Argon2Parallelism,
+		SaltLength:  config.Argon2SaltLength,
+		KeyLength:   config.Argon2KeyLength,
+	})
+}
+
+// newPasswordPolicy creates the policy of new passwords, loading the breached password list when configured
+func newPasswordPolicy(config *config.Config) (*pwpolicy.Policy, error) {
+	var breached *pwpolicy.BreachedList
+	if config.PasswordBreachedListFile != "" {
+		var err error
+		breached, err = pwpolicy.LoadBreachedList(config.PasswordBreachedListFile)
+		if err != nil {
+			return nil, errk.Trace(err)
+		}
+		logk.Get().Infof("Breached password list loaded: %d passwords", breached.Count())
+	}
+
+	rules := pwpolicy.Rules{
+		MinLength:          config.PasswordMinLength,
+		RequireLowercase:   config.PasswordRequireLowercase,
+		RequireUppercase:   config.PasswordRequireUppercase,
+		RequireDigit:       config.PasswordRequireDigit,
+		RequireSymbol:      config.PasswordRequireSymbol,
+		RejectPersonalInfo: config.PasswordRejectPersonalInfo,
+	}
+
+	return pwpolicy.New(rules, breached), nil
+}
+
 func (s *Server) Close() error {
 	s.nats.Close()
 	return s.repo.Close()
 }
 
 func (s *Server) NewService(ctx *f.RequestCtx) (*service.Service, error) {
-	// Get subject from context
+	// Get subject from context. In an impersonation session the admin is the subject of the service,
+	// so every write records the admin as modifiedBy
 	subject := unaryHttpk.GetSubject(ctx)
+	if subject.Actor != nil {
+		subject = *subject.Actor
+	}
 
 	// Get db connection
 	rc, err := s.repo.Connect(ctx)
@@ -78,7 +215,8 @@ func (s *Server) NewService(ctx *f.RequestCtx) (*service.Service, error) {
 			Id:       subject.Id,
 			FullName: subject.FullName,
 			Role:     subject.Role,
-		}), nil
+		}).
+		WithClientIp(httpk.GetClientIP(ctx, "")), nil
 }
 
 func (s *Server) wrapError(ctx *f.RequestCtx, err error) error {
@@ -7,3 +7,9 @@ const (
 const (
 	ServiceName = "svc-core"
 )
+
+// Events of impersonation audit trail
+const (
+	ImpersonationEventStart = "START"
+	ImpersonationEventEnd   = "END"
+)
@@ -1,9 +1,14 @@
 package constant
 
-import "github.com/go-konsultin/errk"
+import (
+	"github.com/go-konsultin/errk"
+	"github.com/valyala/fasthttp"
+)
 
 var (
-	CurrentAuthSessionExpired  = errk.NewError("E_AUTH_2", "Current session has expired")
-	LoginDetectedAnotherDevice = errk.NewError("E_AUTH_3", "Login detected from another device")
-	ResourceNotFound           = errk.NewError("E_NOTFOUND", "Resource not found")
+	CurrentAuthSessionExpired = errk.NewError("E_AUTH_2", "Current session has expired",
+		errk.WithHTTPStatus(fasthttp.StatusUnauthorized))
+	LoginDetectedAnotherDevice = errk.NewError("E_AUTH_3", "Login detected from another device",
+		errk.WithHTTPStatus(fasthttp.StatusUnauthorized))
+	ResourceNotFound = errk.NewError("E_NOTFOUND", "Resource not found")
 )
@@ -1,5 +1,8 @@
 package constant
 
 const (
-	PrivilegeRefreshUserToken = "refresh_user_token"
+	PrivilegeRefreshUserToken  = "refresh_user_token"
+	PrivilegeRevokeUserSession = "revoke_user_session"
+	PrivilegeManageClientAuth  = "manage_client_auth"
+	PrivilegeImpersonateUser   = "impersonate_user"
 )
@@ -1,5 +1,29 @@
 package constant
 
 const (
-	RedisSessionPrefix = "session:"
+	RedisSessionPrefix             = "session:"
+	RedisSubjectSessionPrefix      = "subject-session:"
+	RedisTokenFamilyPrefix         = "token-family:"
+	RedisRotatedRefreshTokenPrefix = "rotated-refresh-token:"
+	RedisPasswordResetTokenPrefix  = "password-reset-token:"
+	RedisUserPasswordResetPrefix   = "user-password-reset:"
+	RedisCredentialVerifyPrefix    = "credential-verification:"
+	RedisVerifyRequestPrefix       = "verification-request:"
+	RedisVerifyCooldownPrefix      = "verification-cooldown:"
+	RedisVerifyAttemptPrefix       = "verification-attempt:"
+	RedisLoginFailurePrefix        = "login-failure:"
+	RedisLoginFailureIpPrefix      = "login-failure-ip:"
+	RedisUserLoginFailurePrefix    = "user-login-failure:"
+	RedisLoginDelayPrefix          = "login-delay:"
+	RedisUserLockoutPrefix         = "user-lockout:"
+	RedisTwoFactorChallengePrefix  = "two-factor-challenge:"
+	RedisTwoFactorAttemptPrefix    = "two-factor-attempt:"
+	RedisTwoFactorFailurePrefix    = "two-factor-failure:"
+	RedisTotpUsedStepPrefix        = "totp-used-step:"
+	RedisLoginOtpPrefix            = "login-otp:"
+	RedisLoginOtpAttemptPrefix     = "login-otp-attempt:"
+	RedisLoginOtpRequestPrefix     = "login-otp-request:"
+	RedisLoginOtpCooldownPrefix    = "login-otp-cooldown:"
+	RedisInactiveClientPrefix      = "inactive-client:"
+	RedisRevokedTokenPrefix        = "revoked-token:"
 )
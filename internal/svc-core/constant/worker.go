@@ -3,3 +3,11 @@ package constant
 const (
 	JobExample = "worker-example"
 )
+
+const (
+	EventSecurity = "security-event"
+)
+
+const (
+	SecurityEventRefreshTokenReuse = "refresh_token_reuse"
+)
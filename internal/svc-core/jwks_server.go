@@ -0,0 +1,24 @@
+package svcCore
+
+import (
+	"encoding/json"
+
+	logkOption "github.com/go-konsultin/logk/option"
+	f "github.com/valyala/fasthttp"
+)
+
+// HandleGetJwks serves public keys used to sign JWT as a raw JSON Web Key Set,
+// so other services can verify our tokens without sharing secrets
+func (s *Server) HandleGetJwks(ctx *f.RequestCtx) {
+	body, err := json.Marshal(s.svc.Jwks())
+	if err != nil {
+		s.log.Error("Failed to marshal jwks", logkOption.Error(err))
+		ctx.Error("Internal Server Error", f.StatusInternalServerError)
+		return
+	}
+
+	ctx.Response.Header.Set("Content-Type", "application/json")
+	ctx.Response.Header.Set("Cache-Control", "public, max-age=300")
+	ctx.SetStatusCode(f.StatusOK)
+	ctx.SetBody(body)
+}
@@ -11,8 +11,10 @@ type AuthSession struct {
 	BaseField
 	Id                    int64                        `db:"id" json:"id"`
 	Xid                   string                       `db:"xid" json:"xid"`
+	FamilyId              string                       `db:"family_id" json:"familyId"`
 	SubjectId             string                       `db:"subject_id" json:"subjectId"`
 	SubjectTypeId         dto.Role_Enum                `db:"subject_type_id" json:"subjectTypeId"`
+	SubjectFullName       string                       `db:"subject_full_name" json:"subjectFullName"`
 	AuthProviderId        dto.AuthProvider_Enum        `db:"auth_provider_id" json:"authProviderId"`
 	DevicePlatformId      dto.DevicePlatform_Enum      `db:"device_platform_id" json:"devicePlatformId"`
 	DeviceId              string                       `db:"device_id" json:"deviceId"`
@@ -21,6 +23,8 @@ type AuthSession struct {
 	NotificationToken     sql.NullString               `db:"notification_token" json:"notificationToken"`
 	ExpiredAt             time.Time                    `db:"expired_at" json:"expiredAt"`
 	StatusId              dto.ControlStatus_Enum       `db:"status_id" json:"statusId"`
+	// Actor is the admin impersonating the subject, nil on sessions created by the subject
+	Actor *Subject `db:"actor" json:"actor,omitempty"`
 }
 
 type AuthSessionDevice struct {
@@ -28,3 +32,14 @@ type AuthSessionDevice struct {
 	DevicePlatformId dto.DevicePlatform_Enum `json:"devicePlatformId"`
 	ClientIp         string                  `json:"clientIp"`
 }
+
+// RefreshTokenRotation records a refresh token that has been exchanged for a new session
+type RefreshTokenRotation struct {
+	SessionXid     string                `json:"sessionXid"`
+	FamilyId       string                `json:"familyId"`
+	SubjectId      string                `json:"subjectId"`
+	AuthProviderId dto.AuthProvider_Enum `json:"authProviderId"`
+	DeviceId       string                `json:"deviceId"`
+	ClientId       string                `json:"clientId,omitempty"`
+	RotatedAt      time.Time             `json:"rotatedAt"`
+}
@@ -9,6 +9,11 @@ import (
 type ClientAuthOptions struct {
 	ClientSecret  string `json:"clientSecret"`
 	TokenLifetime int64  `json:"tokenLifetime"`
+
+	// Secret replaced by the last rotation, accepted until PreviousClientSecretExpiredAt (unix seconds)
+	PreviousClientSecret          string `json:"previousClientSecret,omitempty"`
+	PreviousClientSecretExpiredAt int64  `json:"previousClientSecretExpiredAt,omitempty"`
+	ClientSecretRotatedAt         int64  `json:"clientSecretRotatedAt,omitempty"`
 }
 
 func (m *ClientAuthOptions) Scan(src interface{}) error {
@@ -0,0 +1,11 @@
+package model
+
+import "time"
+
+// CredentialVerification is a pending verification of a credential identifier, holding the hash of the code sent to it
+type CredentialVerification struct {
+	CredentialId int64     `json:"credentialId"`
+	UserId       int64     `json:"userId"`
+	CodeHash     string    `json:"codeHash"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
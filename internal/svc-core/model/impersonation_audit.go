@@ -0,0 +1,28 @@
+package model
+
+import (
+	"encoding/json"
+
+	"github.com/go-konsultin/timek"
+)
+
+// ImpersonationAudit records the start or end of an impersonation session. Actor is the subject causing the event,
+// the admin on start, and the admin, the user or a client on end
+type ImpersonationAudit struct {
+	Id         int64           `db:"id"`
+	SessionXid string          `db:"sessionXid"`
+	Event      string          `db:"event"`
+	AdminId    string          `db:"adminId"`
+	UserXid    string          `db:"userXid"`
+	Reason     string          `db:"reason"`
+	ClientIp   string          `db:"clientIp"`
+	Actor      *Subject        `db:"actor"`
+	Metadata   json.RawMessage `db:"metadata"`
+	CreatedAt  timek.Time      `db:"createdAt"`
+}
+
+// ImpersonationAuditMetadata is the metadata of a START event
+type ImpersonationAuditMetadata struct {
+	Privileges []string `json:"privileges"`
+	ExpiredAt  int64    `json:"expiredAt"`
+}
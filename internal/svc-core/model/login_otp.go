@@ -0,0 +1,11 @@
+package model
+
+import "time"
+
+// LoginOtp is a one-time code sent to email or phone of a user to login without password
+type LoginOtp struct {
+	UserId     int64     `json:"userId"`
+	Identifier string    `json:"identifier"`
+	CodeHash   string    `json:"codeHash"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
@@ -0,0 +1,11 @@
+package model
+
+import "time"
+
+// PasswordResetToken is a pending password reset, stored under the hash of the token sent to the user
+type PasswordResetToken struct {
+	UserId    int64     `json:"userId"`
+	UserXid   string    `json:"userXid"`
+	Recipient string    `json:"recipient"`
+	CreatedAt time.Time `json:"createdAt"`
+}
@@ -1,11 +1,27 @@
 package model
 
-import "github.com/konsultin/project-goes-here/dto"
+import (
+	"database/sql/driver"
+	"encoding/json"
+
+	"github.com/go-konsultin/sqlk"
+	"github.com/konsultin/project-goes-here/dto"
+)
 
 type Subject struct {
 	Id       string `json:"id"`
 	Role     string `json:"role"`
 	FullName string `json:"fullName"`
+	// Actor is the admin acting as the subject in an impersonation session
+	Actor *Subject `json:"actor,omitempty"`
+}
+
+func (m *Subject) Scan(src interface{}) error {
+	return sqlk.ScanJSON(src, m)
+}
+
+func (m *Subject) Value() (driver.Value, error) {
+	return json.Marshal(m)
 }
 
 func NewSubject(d *dto.Subject) *Subject {
@@ -0,0 +1,16 @@
+package model
+
+import (
+	"time"
+
+	"github.com/konsultin/project-goes-here/dto"
+)
+
+// TwoFactorChallenge is a login that passed the first factor, waiting for a TOTP or recovery code to create the session
+type TwoFactorChallenge struct {
+	UserId         int64                 `json:"userId"`
+	AuthProviderId dto.AuthProvider_Enum `json:"authProviderId"`
+	Identifier     string                `json:"identifier"` // login identifier, invalid codes count as its failed logins
+	Device         *dto.DeviceSession    `json:"device,omitempty"`
+	CreatedAt      time.Time             `json:"createdAt"`
+}
@@ -0,0 +1,10 @@
+package model
+
+import "time"
+
+// UserLockout is a temporary lock of a user after too many failed login attempts
+type UserLockout struct {
+	UserId   int64     `json:"userId"`
+	LockedAt time.Time `json:"lockedAt"`
+	UnlockAt time.Time `json:"unlockAt"`
+}
@@ -0,0 +1,16 @@
+package model
+
+import (
+	"database/sql"
+
+	"github.com/go-konsultin/timek"
+)
+
+// UserRecoveryCode is a single-use code replacing the TOTP code when the authenticator device is lost
+type UserRecoveryCode struct {
+	Id        int64        `db:"id"`
+	UserId    int64        `db:"userId"`
+	CodeHash  string       `db:"codeHash"`
+	UsedAt    sql.NullTime `db:"usedAt"`
+	CreatedAt timek.Time   `db:"createdAt"`
+}
@@ -0,0 +1,233 @@
+package svcCore
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/url"
+
+	logkOption "github.com/go-konsultin/logk/option"
+	"github.com/konsultin/project-goes-here/dto"
+	unaryHttpk "github.com/konsultin/project-goes-here/internal/svc-core/pkg/httpk/unary"
+	"github.com/konsultin/project-goes-here/internal/svc-core/service"
+	f "github.com/valyala/fasthttp"
+)
+
+// HandleOAuthToken handles OAuth 2.0 token requests. Responses follow RFC 6749 instead of the common response,
+// so off-the-shelf OAuth client libraries can be used
+// @Summary      Issue OAuth access token
+// @Description  Issue an access token with client_credentials grant. Client authenticates with Basic auth or client_id and client_secret form fields
+// @Tags         oauth
+// @Accept       x-www-form-urlencoded
+// @Produce      json
+// @Param        grant_type     formData  string  true   "Grant type, only client_credentials is supported"
+// @Param        client_id      formData  string  false  "Client id, when Basic auth is not used"
+// @Param        client_secret  formData  string  false  "Client secret, when Basic auth is not used"
+// @Param        scope          formData  string  false  "Space-delimited privileges, defaults to every privilege of the client"
+// @Success      200  {object}  dto.OAuthToken_Result
+// @Failure      400  {object}  dto.OAuthError_Result "Invalid Request"
+// @Failure      401  {object}  dto.OAuthError_Result "Invalid Client"
+// @Failure      500  {object}  dto.OAuthError_Result "Server Error"
+// @Router       /v1/oauth/token [post]
+func (s *Server) HandleOAuthToken(ctx *f.RequestCtx) {
+	credentials, err := getOAuthClientCredentials(ctx)
+	if err != nil {
+		s.writeOAuthError(ctx, err)
+		return
+	}
+
+	args := ctx.PostArgs()
+	payload := &dto.OAuthToken_Payload{
+		GrantType: string(args.Peek("grant_type")),
+		Scope:     string(args.Peek("scope")),
+	}
+
+	// Init Service
+	svc, err := s.NewService(ctx)
+	if err != nil {
+		s.log.Errorf("Failed to create service: %v", err)
+		s.writeOAuthError(ctx, err)
+		return
+	}
+	defer svc.Close()
+
+	result, err := svc.IssueOAuthToken(credentials, payload)
+	if err != nil {
+		s.writeOAuthError(ctx, err)
+		return
+	}
+
+	s.writeOAuthResult(ctx, f.StatusOK, result)
+}
+
+// HandleOAuthIntrospect handles OAuth 2.0 token introspection, RFC 7662
+// @Summary      Introspect token
+// @Description  Check whether a token is active and get its claims. Client authenticates with Basic auth or client_id and client_secret form fields, only service clients are allowed
+// @Tags         oauth
+// @Accept       x-www-form-urlencoded
+// @Produce      json
+// @Param        token            formData  string  true   "Access or refresh token"
+// @Param        token_type_hint  formData  string  false  "access_token or refresh_token"
+// @Success      200  {object}  dto.OAuthTokenIntrospection_Result
+// @Failure      400  {object}  dto.OAuthError_Result "Invalid Request"
+// @Failure      401  {object}  dto.OAuthError_Result "Invalid Client"
+// @Failure      500  {object}  dto.OAuthError_Result "Server Error"
+// @Router       /v1/oauth/introspect [post]
+func (s *Server) HandleOAuthIntrospect(ctx *f.RequestCtx) {
+	credentials, err := getOAuthClientCredentials(ctx)
+	if err != nil {
+		s.writeOAuthError(ctx, err)
+		return
+	}
+
+	args := ctx.PostArgs()
+	payload := &dto.OAuthTokenIntrospection_Payload{
+		Token:         string(args.Peek("token")),
+		TokenTypeHint: string(args.Peek("token_type_hint")),
+	}
+
+	// Init Service
+	svc, err := s.NewService(ctx)
+	if err != nil {
+		s.log.Errorf("Failed to create service: %v", err)
+		s.writeOAuthError(ctx, err)
+		return
+	}
+	defer svc.Close()
+
+	result, err := svc.IntrospectOAuthToken(credentials, payload)
+	if err != nil {
+		s.writeOAuthError(ctx, err)
+		return
+	}
+
+	s.writeOAuthResult(ctx, f.StatusOK, result)
+}
+
+// HandleOAuthRevoke handles OAuth 2.0 token revocation, RFC 7009
+// @Summary      Revoke token
+// @Description  Revoke an access or refresh token, user tokens revoke their session. Client authenticates with Basic auth or client_id and client_secret form fields
+// @Tags         oauth
+// @Accept       x-www-form-urlencoded
+// @Produce      json
+// @Param        token            formData  string  true   "Access or refresh token"
+// @Param        token_type_hint  formData  string  false  "access_token or refresh_token"
+// @Success      200
+// @Failure      400  {object}  dto.OAuthError_Result "Invalid Request"
+// @Failure      401  {object}  dto.OAuthError_Result "Invalid Client"
+// @Failure      500  {object}  dto.OAuthError_Result "Server Error"
+// @Router       /v1/oauth/revoke [post]
+func (s *Server) HandleOAuthRevoke(ctx *f.RequestCtx) {
+	credentials, err := getOAuthClientCredentials(ctx)
+	if err != nil {
+		s.writeOAuthError(ctx, err)
+		return
+	}
+
+	args := ctx.PostArgs()
+	payload := &dto.OAuthTokenRevocation_Payload{
+		Token:         string(args.Peek("token")),
+		TokenTypeHint: string(args.Peek("token_type_hint")),
+	}
+
+	// Init Service
+	svc, err := s.NewService(ctx)
+	if err != nil {
+		s.log.Errorf("Failed to create service: %v", err)
+		s.writeOAuthError(ctx, err)
+		return
+	}
+	defer svc.Close()
+
+	err = svc.RevokeOAuthToken(credentials, payload)
+	if err != nil {
+		s.writeOAuthError(ctx, err)
+		return
+	}
+
+	// Response has no content, the token is revoked or was already invalid
+	ctx.Response.Header.Set("Cache-Control", "no-store")
+	ctx.Response.Header.Set("Pragma", "no-cache")
+	ctx.SetStatusCode(f.StatusOK)
+}
+
+// getOAuthClientCredentials returns client authentication of an OAuth request from Basic auth or form fields.
+// Using both is rejected, RFC 6749 section 2.3
+func getOAuthClientCredentials(ctx *f.RequestCtx) (*service.OAuthClientCredentials, error) {
+	if !bytes.HasPrefix(ctx.Request.Header.ContentType(), []byte("application/x-www-form-urlencoded")) {
+		return nil, &service.OAuthError{
+			Code:        service.OAuthErrorInvalidRequest,
+			Description: "request body must be application/x-www-form-urlencoded",
+		}
+	}
+
+	args := ctx.PostArgs()
+	formClientId := string(args.Peek("client_id"))
+	formClientSecret := string(args.Peek("client_secret"))
+
+	basicAuth := unaryHttpk.GetBasicAuth(ctx)
+	if basicAuth == nil {
+		if formClientId == "" {
+			return nil, nil
+		}
+		return &service.OAuthClientCredentials{ClientId: formClientId, ClientSecret: formClientSecret}, nil
+	}
+
+	if formClientSecret != "" {
+		return nil, &service.OAuthError{
+			Code:        service.OAuthErrorInvalidRequest,
+			Description: "client must use only one authentication method",
+		}
+	}
+
+	// Basic auth credentials are form-urlencoded before being encoded, RFC 6749 section 2.3.1
+	clientId, err := url.QueryUnescape(basicAuth.Username)
+	if err != nil {
+		clientId = basicAuth.Username
+	}
+	clientSecret, err := url.QueryUnescape(basicAuth.Password)
+	if err != nil {
+		clientSecret = basicAuth.Password
+	}
+
+	return &service.OAuthClientCredentials{ClientId: clientId, ClientSecret: clientSecret}, nil
+}
+
+// writeOAuthError renders an RFC 6749 error response, errors other than OAuthError are rendered as server_error
+func (s *Server) writeOAuthError(ctx *f.RequestCtx, err error) {
+	var oauthErr *service.OAuthError
+	if !errors.As(err, &oauthErr) {
+		s.log.Errorf("Error returned from Service. ErrorType=%T Error=%+v", err, err)
+		s.writeOAuthResult(ctx, f.StatusInternalServerError, &dto.OAuthError_Result{
+			Error: service.OAuthErrorServerError,
+		})
+		return
+	}
+
+	status := f.StatusBadRequest
+	if oauthErr.Code == service.OAuthErrorInvalidClient {
+		status = f.StatusUnauthorized
+		ctx.Response.Header.Set("WWW-Authenticate", `Basic realm="oauth"`)
+	}
+
+	s.writeOAuthResult(ctx, status, &dto.OAuthError_Result{
+		Error:            oauthErr.Code,
+		ErrorDescription: oauthErr.Description,
+	})
+}
+
+// writeOAuthResult renders a raw JSON response that must not be cached, RFC 6749 section 5.1
+func (s *Server) writeOAuthResult(ctx *f.RequestCtx, status int, result any) {
+	body, err := json.Marshal(result)
+	if err != nil {
+		s.log.Error("Failed to marshal oauth response", logkOption.Error(err))
+		ctx.Error("Internal Server Error", f.StatusInternalServerError)
+		return
+	}
+
+	ctx.Response.Header.Set("Content-Type", "application/json;charset=UTF-8")
+	ctx.Response.Header.Set("Cache-Control", "no-store")
+	ctx.Response.Header.Set("Pragma", "no-cache")
+	ctx.SetStatusCode(status)
+	ctx.SetBody(body)
+}
@@ -0,0 +1,75 @@
+package hasher
+
+import (
+	"errors"
+	"sync"
+)
+
+// dummySecret is hashed once to verify against when there is no hash of the user
+const dummySecret = "dummy-secret"
+
+// ErrUnknownHash is returned when the hash is not produced by any supported algorithm
+var ErrUnknownHash = errors.New("hasher: unknown hash format")
+
+// Hasher hashes secrets such as passwords and client secrets
+type Hasher interface {
+	// GetAlgorithm returns the algorithm name
+	GetAlgorithm() string
+	// Hash returns the encoded hash of the secret, including algorithm and parameters
+	Hash(secret string) (string, error)
+	// Verify checks the secret against a hash produced by this algorithm
+	Verify(hash, secret string) (bool, error)
+	// Supports checks if the hash is produced by this algorithm
+	Supports(hash string) bool
+	// NeedsRehash checks if the hash is produced with parameters other than the current ones
+	NeedsRehash(hash string) bool
+}
+
+// Policy hashes new secrets with the primary algorithm, and still verifies hashes of the legacy algorithms
+type Policy struct {
+	primary Hasher
+	legacy  []Hasher
+
+	dummyOnce sync.Once
+	dummyHash string
+}
+
+// NewPolicy creates a policy hashing with primary, accepting hashes of legacy algorithms
+func NewPolicy(primary Hasher, legacy ...Hasher) *Policy {
+	return &Policy{primary: primary, legacy: legacy}
+}
+
+// Hash hashes the secret with the primary algorithm
+func (p *Policy) Hash(secret string) (string, error) {
+	return p.primary.Hash(secret)
+}
+
+// Verify checks the secret against a hash of any supported algorithm. needsRehash is set if secret is valid
+// but the hash is produced by a legacy algorithm or outdated parameters, so it should be replaced by Hash(secret)
+func (p *Policy) Verify(hash, secret string) (valid bool, needsRehash bool, err error) {
+	if p.primary.Supports(hash) {
+		valid, err = p.primary.Verify(hash, secret)
+		return valid, valid && p.primary.NeedsRehash(hash), err
+	}
+
+	for _, h := range p.legacy {
+		if h.Supports(hash) {
+			valid, err = h.Verify(hash, secret)
+			return valid, valid, err
+		}
+	}
+
+	return false, false, ErrUnknownHash
+}
+
+// VerifyDummy verifies the secret against a fixed hash of the primary algorithm and discards the result. Used when
+// there is no hash to verify, so response time does not reveal whether the user or its secret exists
+func (p *Policy) VerifyDummy(secret string) {
+	p.dummyOnce.Do(func() {
+		p.dummyHash, _ = p.primary.Hash(dummySecret)
+	})
+	if p.dummyHash == "" {
+		return
+	}
+	_, _ = p.primary.Verify(p.dummyHash, secret)
+}
@@ -0,0 +1,251 @@
+package hasher
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Cheap parameters, so tests do not spend the cost of production hashes
+var testArgon2idParams = Argon2idParams{
+	Memory:      64,
+	Iterations:  1,
+	Parallelism: 1,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+func mustHash(t *testing.T, h Hasher, secret string) string {
+	t.Helper()
+	hash, err := h.Hash(secret)
+	if err != nil {
+		t.Fatalf("%s Hash() error = %v", h.GetAlgorithm(), err)
+	}
+	return hash
+}
+
+func TestDecodeArgon2id(t *testing.T) {
+	// Salt "saltsaltsaltsalt" and a 4 byte key
+	const salt = "c2FsdHNhbHRzYWx0c2FsdA"
+	const key = "a2V5IQ"
+
+	tests := []struct {
+		name       string
+		hash       string
+		wantParams *Argon2idParams
+		wantErr    bool
+	}{
+		{
+			name:       "valid",
+			hash:       "$argon2id$v=19$m=65536,t=3,p=2$" + salt + "$" + key,
+			wantParams: &Argon2idParams{Memory: 65536, Iterations: 3, Parallelism: 2, SaltLength: 16, KeyLength: 4},
+		},
+		{name: "missing key", hash: "$argon2id$v=19$m=65536,t=3,p=2$" + salt, wantErr: true},
+		{name: "other algorithm", hash: "$argon2i$v=19$m=65536,t=3,p=2$" + salt + "$" + key, wantErr: true},
+		{name: "unsupported version", hash: "$argon2id$v=16$m=65536,t=3,p=2$" + salt + "$" + key, wantErr: true},
+		{name: "malformed version", hash: "$argon2id$version$m=65536,t=3,p=2$" + salt + "$" + key, wantErr: true},
+		{name: "malformed parameters", hash: "$argon2id$v=19$m=65536$" + salt + "$" + key, wantErr: true},
+		{name: "malformed salt", hash: "$argon2id$v=19$m=65536,t=3,p=2$not-base64!$" + key, wantErr: true},
+		{name: "malformed key", hash: "$argon2id$v=19$m=65536,t=3,p=2$" + salt + "$not-base64!", wantErr: true},
+		{name: "bcrypt hash", hash: "$2a$10$N9qo8uLOickgx2ZMRZoMyeIjZAgcfl7p92ldGxad68LJZdL17lhWy", wantErr: true},
+		{name: "empty", hash: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params, _, _, err := decodeArgon2id(tt.hash)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("decodeArgon2id() error = %v, wantErr %t", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if *params != *tt.wantParams {
+				t.Errorf("decodeArgon2id() params = %+v, want %+v", *params, *tt.wantParams)
+			}
+		})
+	}
+}
+
+func TestArgon2id(t *testing.T) {
+	h := NewArgon2id(testArgon2idParams)
+	hash := mustHash(t, h, "correct horse")
+
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=64,t=1,p=1$") {
+		t.Errorf("Hash() = %q, want PHC string of the parameters", hash)
+	}
+	if !h.Supports(hash) {
+		t.Errorf("Supports() = false for its own hash")
+	}
+	if other := mustHash(t, h, "correct horse"); other == hash {
+		t.Errorf("Hash() returned the same hash twice, salt is not random")
+	}
+
+	tests := []struct {
+		name   string
+		secret string
+		want   bool
+	}{
+		{name: "same secret", secret: "correct horse", want: true},
+		{name: "other secret", secret: "correct horse!"},
+		{name: "empty secret", secret: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := h.Verify(hash, tt.secret)
+			if err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Verify() = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestArgon2idNeedsRehash(t *testing.T) {
+	h := NewArgon2id(testArgon2idParams)
+
+	tests := []struct {
+		name   string
+		params func(p *Argon2idParams)
+		hash   string
+		want   bool
+	}{
+		{name: "current parameters", params: func(p *Argon2idParams) {}},
+		{name: "other memory", params: func(p *Argon2idParams) { p.Memory = 128 }, want: true},
+		{name: "other iterations", params: func(p *Argon2idParams) { p.Iterations = 2 }, want: true},
+		{name: "other parallelism", params: func(p *Argon2idParams) { p.Parallelism = 2 }, want: true},
+		{name: "other salt length", params: func(p *Argon2idParams) { p.SaltLength = 8 }, want: true},
+		{name: "other key length", params: func(p *Argon2idParams) { p.KeyLength = 16 }, want: true},
+		{name: "malformed hash", hash: "$argon2id$v=19$m=64", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hash := tt.hash
+			if tt.params != nil {
+				params := testArgon2idParams
+				tt.params(&params)
+				hash = mustHash(t, NewArgon2id(params), "secret")
+			}
+
+			if got := h.NeedsRehash(hash); got != tt.want {
+				t.Errorf("NeedsRehash() = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBcrypt(t *testing.T) {
+	h := NewBcrypt(bcrypt.MinCost)
+	hash := mustHash(t, h, "correct horse")
+
+	if valid, err := h.Verify(hash, "correct horse"); err != nil || !valid {
+		t.Errorf("Verify() of same secret = (%t, %v), want (true, nil)", valid, err)
+	}
+	if valid, err := h.Verify(hash, "correct horse!"); err != nil || valid {
+		t.Errorf("Verify() of other secret = (%t, %v), want (false, nil)", valid, err)
+	}
+	if _, err := h.Verify("$2a$malformed", "correct horse"); err == nil {
+		t.Errorf("Verify() of malformed hash error = nil, want error")
+	}
+
+	tests := []struct {
+		name            string
+		hash            string
+		wantSupports    bool
+		wantNeedsRehash bool
+	}{
+		{name: "current cost", hash: hash, wantSupports: true},
+		{name: "other cost", hash: mustHash(t, NewBcrypt(bcrypt.MinCost+1), "secret"), wantSupports: true, wantNeedsRehash: true},
+		{name: "2y prefix", hash: "$2y$04$" + hash[7:], wantSupports: true},
+		{name: "argon2id hash", hash: "$argon2id$v=19$m=64,t=1,p=1$c2FsdA$a2V5", wantNeedsRehash: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := h.Supports(tt.hash); got != tt.wantSupports {
+				t.Errorf("Supports() = %t, want %t", got, tt.wantSupports)
+			}
+			if got := h.NeedsRehash(tt.hash); got != tt.wantNeedsRehash {
+				t.Errorf("NeedsRehash() = %t, want %t", got, tt.wantNeedsRehash)
+			}
+		})
+	}
+}
+
+func TestPolicyVerify(t *testing.T) {
+	argon2id := NewArgon2id(testArgon2idParams)
+	bcryptHasher := NewBcrypt(bcrypt.MinCost)
+	policy := NewPolicy(argon2id, bcryptHasher)
+
+	outdated := testArgon2idParams
+	outdated.Iterations = 2
+
+	tests := []struct {
+		name            string
+		hash            string
+		secret          string
+		wantValid       bool
+		wantNeedsRehash bool
+		wantErr         error
+	}{
+		{name: "primary", hash: mustHash(t, argon2id, "secret"), secret: "secret", wantValid: true},
+		{name: "primary with other secret", hash: mustHash(t, argon2id, "secret"), secret: "other"},
+		{
+			name:            "primary with outdated parameters",
+			hash:            mustHash(t, NewArgon2id(outdated), "secret"),
+			secret:          "secret",
+			wantValid:       true,
+			wantNeedsRehash: true,
+		},
+		{name: "outdated with other secret", hash: mustHash(t, NewArgon2id(outdated), "secret"), secret: "other"},
+		{name: "legacy", hash: mustHash(t, bcryptHasher, "secret"), secret: "secret", wantValid: true, wantNeedsRehash: true},
+		{name: "legacy with other secret", hash: mustHash(t, bcryptHasher, "secret"), secret: "other"},
+		{name: "unknown", hash: "5ebe2294ecd0e0f08eab7690d2a6ee69", secret: "secret", wantErr: ErrUnknownHash},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			valid, needsRehash, err := policy.Verify(tt.hash, tt.secret)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Verify() error = %v, want %v", err, tt.wantErr)
+			}
+			if valid != tt.wantValid || needsRehash != tt.wantNeedsRehash {
+				t.Errorf("Verify() = (%t, %t), want (%t, %t)", valid, needsRehash, tt.wantValid, tt.wantNeedsRehash)
+			}
+		})
+	}
+}
+
+func TestPolicyHash(t *testing.T) {
+	policy := NewPolicy(NewBcrypt(bcrypt.MinCost), NewArgon2id(testArgon2idParams))
+
+	hash, err := policy.Hash("secret")
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+	if !strings.HasPrefix(hash, "$2a$") {
+		t.Errorf("Hash() = %q, want hash of primary algorithm", hash)
+	}
+}
+
+func TestPolicyVerifyDummy(t *testing.T) {
+	policy := NewPolicy(NewArgon2id(testArgon2idParams), NewBcrypt(bcrypt.MinCost))
+
+	// Dummy verification has no result, the dummy hash is computed once and reused
+	policy.VerifyDummy("secret")
+	hash := policy.dummyHash
+	if !strings.HasPrefix(hash, "$argon2id$") {
+		t.Fatalf("dummy hash = %q, want hash of primary algorithm", hash)
+	}
+
+	policy.VerifyDummy("other")
+	if policy.dummyHash != hash {
+		t.Errorf("VerifyDummy() computed the dummy hash again")
+	}
+}
@@ -7,9 +7,24 @@ import (
 const SubjectKey = "subject"
 
 type Subject struct {
-	Id       string `json:"id"`
-	FullName string `json:"fullName"`
-	Role     string `json:"role"`
+	Id         string   `json:"id"`
+	FullName   string   `json:"fullName"`
+	Role       string   `json:"role"`
+	Privileges []string `json:"privileges,omitempty"`
+	// Restricted subject is only authorized on routes requiring one of its privileges
+	Restricted bool `json:"restricted,omitempty"`
+	// Actor is the admin acting as the subject in an impersonation session
+	Actor *Subject `json:"actor,omitempty"`
+}
+
+// HasPrivilege checks if subject bearer token is granted with the privilege
+func (s Subject) HasPrivilege(privilege string) bool {
+	for _, p := range s.Privileges {
+		if p == privilege {
+			return true
+		}
+	}
+	return false
 }
 
 var AnonymousSubject = Subject{
@@ -33,24 +48,3 @@ func GetSubject(ctx *f.RequestCtx) Subject {
 	}
 	return val
 }
-
-func ExtractSubject(next f.RequestHandler) f.RequestHandler {
-	return func(ctx *f.RequestCtx) {
-		subject := parseSubjectFromHeader(ctx)
-		ctx.SetUserValue(SubjectKey, subject)
-		next(ctx)
-	}
-}
-
-func parseSubjectFromHeader(ctx *f.RequestCtx) Subject {
-	id := string(ctx.Request.Header.Peek("X-User-Id"))
-	if id == "" {
-		return AnonymousSubject
-	}
-
-	return Subject{
-		Id:       id,
-		FullName: string(ctx.Request.Header.Peek("X-User-Name")),
-		Role:     string(ctx.Request.Header.Peek("X-User-Role")),
-	}
-}
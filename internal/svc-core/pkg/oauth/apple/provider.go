@@ -0,0 +1,171 @@
+package apple
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/konsultin/project-goes-here/dto"
+	"github.com/konsultin/project-goes-here/internal/svc-core/pkg/oauth/jwks"
+)
+
+const (
+	issuer  = "https://appleid.apple.com"
+	keysURL = "https://appleid.apple.com/auth/keys"
+
+	privateRelayDomain = "@privaterelay.appleid.com"
+	defaultFullName    = "Apple User"
+)
+
+// defaultKeySource is shared by providers so Apple public keys are cached across requests
+var defaultKeySource jwks.KeySource = jwks.NewRemoteKeySet(keysURL)
+
+// Provider implements Sign in with Apple authentication
+type Provider struct {
+	clientID  string
+	keySource jwks.KeySource
+}
+
+// NewProvider creates a new Sign in with Apple provider
+func NewProvider(clientID string) *Provider {
+	return &Provider{
+		clientID:  clientID,
+		keySource: defaultKeySource,
+	}
+}
+
+// WithKeySource returns a copy of the provider that resolves signing keys from the given source
+func (p *Provider) WithKeySource(keySource jwks.KeySource) *Provider {
+	newP := *p
+	newP.keySource = keySource
+	return &newP
+}
+
+// IdentityTokenClaims represents the claims of Apple identity token
+type IdentityTokenClaims struct {
+	jwt.RegisteredClaims
+	Email          string  `json:"email"`
+	EmailVerified  boolish `json:"email_verified"`
+	IsPrivateEmail boolish `json:"is_private_email"`
+	Nonce          string  `json:"nonce"`
+}
+
+// VerifyToken verifies Apple identity token and returns user info.
+// payload.Nonce is the raw nonce generated by client, its SHA-256 hash must match the token nonce claim
+func (p *Provider) VerifyToken(ctx context.Context, payload *dto.LoginOAuth_Payload) (*dto.OAuthUserInfo, error) {
+	keyFunc := func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		kid, _ := token.Header["kid"].(string)
+		return p.keySource.GetKey(ctx, kid)
+	}
+
+	// Parse validates exp, iat and nbf claims only when they are present
+	var claims IdentityTokenClaims
+	_, err := jwt.ParseWithClaims(payload.IdToken, &claims, keyFunc)
+	if err != nil {
+		return nil, fmt.Errorf("invalid token: %w", err)
+	}
+
+	// Verify expiration, it is required
+	if !claims.VerifyExpiresAt(time.Now(), true) {
+		return nil, fmt.Errorf("invalid token: token is expired or has no expiration")
+	}
+
+	// Verify issuer
+	if claims.Issuer != issuer {
+		return nil, fmt.Errorf("invalid issuer: %s", claims.Issuer)
+	}
+
+	// Verify audience matches our client ID
+	if !claims.VerifyAudience(p.clientID, true) {
+		return nil, fmt.Errorf("invalid audience: expected %s, got %s", p.clientID, strings.Join(claims.Audience, ","))
+	}
+
+	// Verify nonce to prevent token replay
+	if err = verifyNonce(claims.Nonce, payload.Nonce); err != nil {
+		return nil, err
+	}
+
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("invalid token: missing subject")
+	}
+
+	email := strings.ToLower(claims.Email)
+	isPrivateEmail := bool(claims.IsPrivateEmail) || strings.HasSuffix(email, privateRelayDomain)
+
+	return &dto.OAuthUserInfo{
+		ProviderId:     claims.Subject,
+		Email:          email,
+		Name:           composeFullName(payload.FullName, email, isPrivateEmail),
+		EmailVerified:  bool(claims.EmailVerified),
+		IsPrivateEmail: isPrivateEmail,
+	}, nil
+}
+
+// composeFullName resolves user's name, Apple never includes it in identity token
+// and client only receives it on first login
+func composeFullName(fullName string, email string, isPrivateEmail bool) string {
+	name := strings.TrimSpace(fullName)
+	if name == "" && !isPrivateEmail && email != "" {
+		name = strings.SplitN(email, "@", 2)[0]
+	}
+	if name == "" {
+		name = defaultFullName
+	}
+	return name
+}
+
+// verifyNonce checks the token nonce is SHA-256 hex digest of the request nonce. Nonce is required, a token without
+// nonce could be replayed
+func verifyNonce(tokenNonce string, nonce string) error {
+	if tokenNonce == "" || nonce == "" {
+		return fmt.Errorf("invalid nonce: nonce is missing")
+	}
+
+	sum := sha256.Sum256([]byte(nonce))
+	expected := hex.EncodeToString(sum[:])
+	if subtle.ConstantTimeCompare([]byte(expected), []byte(tokenNonce)) != 1 {
+		return fmt.Errorf("invalid nonce: nonce did not match")
+	}
+
+	return nil
+}
+
+// GetProviderName returns the provider name
+func (p *Provider) GetProviderName() string {
+	return "apple"
+}
+
+// GetProviderId returns the provider enum value
+func (p *Provider) GetProviderId() dto.AuthProvider_Enum {
+	return dto.AuthProvider_APPLE
+}
+
+// boolish decodes Apple boolean claims that are sent either as JSON boolean or "true"/"false" string
+type boolish bool
+
+func (b *boolish) UnmarshalJSON(data []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	switch val := v.(type) {
+	case bool:
+		*b = boolish(val)
+	case string:
+		*b = boolish(val == "true")
+	default:
+		*b = false
+	}
+
+	return nil
+}
@@ -0,0 +1,160 @@
+package apple
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/konsultin/project-goes-here/dto"
+	"github.com/konsultin/project-goes-here/internal/svc-core/pkg/oauth/jwks"
+)
+
+const (
+	testClientID = "com.example.app"
+	testKid      = "test-key"
+	testNonce    = "raw-nonce"
+)
+
+func newTestKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	return key
+}
+
+func hashNonce(nonce string) string {
+	sum := sha256.Sum256([]byte(nonce))
+	return hex.EncodeToString(sum[:])
+}
+
+func validClaims() jwt.MapClaims {
+	now := time.Now()
+	return jwt.MapClaims{
+		"iss":            issuer,
+		"sub":            "001234.abcdef",
+		"aud":            testClientID,
+		"iat":            now.Unix(),
+		"exp":            now.Add(time.Hour).Unix(),
+		"email":          "Jane@Example.com",
+		"email_verified": "true",
+		"nonce":          hashNonce(testNonce),
+	}
+}
+
+func signToken(t *testing.T, key *rsa.PrivateKey, claims jwt.MapClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = testKid
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+	return signed
+}
+
+func TestVerifyToken(t *testing.T) {
+	key := newTestKey(t)
+	provider := NewProvider(testClientID).WithKeySource(jwks.StaticKeySet{testKid: &key.PublicKey})
+
+	tests := []struct {
+		name      string
+		modify    func(c jwt.MapClaims)
+		nonce     string
+		omitNonce bool
+		fullName  string
+		wantErr   string
+		want      *dto.OAuthUserInfo
+	}{
+		{
+			name:     "valid",
+			fullName: " Jane Doe ",
+			want:     &dto.OAuthUserInfo{ProviderId: "001234.abcdef", Email: "jane@example.com", Name: "Jane Doe", EmailVerified: true},
+		},
+		{
+			name: "name from email",
+			want: &dto.OAuthUserInfo{ProviderId: "001234.abcdef", Email: "jane@example.com", Name: "jane", EmailVerified: true},
+		},
+		{
+			name: "private relay flag",
+			modify: func(c jwt.MapClaims) {
+				c["email"] = "abc123@privaterelay.appleid.com"
+				c["is_private_email"] = true
+			},
+			want: &dto.OAuthUserInfo{ProviderId: "001234.abcdef", Email: "abc123@privaterelay.appleid.com", Name: defaultFullName, EmailVerified: true, IsPrivateEmail: true},
+		},
+		{
+			name:   "private relay flag as string",
+			modify: func(c jwt.MapClaims) { c["is_private_email"] = "true" },
+			want:   &dto.OAuthUserInfo{ProviderId: "001234.abcdef", Email: "jane@example.com", Name: defaultFullName, EmailVerified: true, IsPrivateEmail: true},
+		},
+		{
+			name:   "private relay domain without flag",
+			modify: func(c jwt.MapClaims) { c["email"] = "abc123@privaterelay.appleid.com" },
+			want:   &dto.OAuthUserInfo{ProviderId: "001234.abcdef", Email: "abc123@privaterelay.appleid.com", Name: defaultFullName, EmailVerified: true, IsPrivateEmail: true},
+		},
+		{name: "nonce mismatch", nonce: "other-nonce", wantErr: "nonce did not match"},
+		{name: "nonce missing from request", omitNonce: true, wantErr: "nonce is missing"},
+		{name: "nonce missing from token", modify: func(c jwt.MapClaims) { delete(c, "nonce") }, wantErr: "nonce is missing"},
+		{name: "raw nonce in token", modify: func(c jwt.MapClaims) { c["nonce"] = testNonce }, wantErr: "nonce did not match"},
+		{name: "bad audience", modify: func(c jwt.MapClaims) { c["aud"] = "com.example.other" }, wantErr: "invalid audience"},
+		{name: "bad issuer", modify: func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" }, wantErr: "invalid issuer"},
+		{name: "expired", modify: func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Minute).Unix() }, wantErr: "invalid token"},
+		{name: "missing expiration", modify: func(c jwt.MapClaims) { delete(c, "exp") }, wantErr: "invalid token"},
+		{name: "missing subject", modify: func(c jwt.MapClaims) { delete(c, "sub") }, wantErr: "missing subject"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := validClaims()
+			if tt.modify != nil {
+				tt.modify(claims)
+			}
+
+			nonce := testNonce
+			if tt.nonce != "" {
+				nonce = tt.nonce
+			}
+			if tt.omitNonce {
+				nonce = ""
+			}
+
+			info, err := provider.VerifyToken(context.Background(), &dto.LoginOAuth_Payload{
+				IdToken:  signToken(t, key, claims),
+				Nonce:    nonce,
+				FullName: tt.fullName,
+			})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("VerifyToken() error = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("VerifyToken() error = %v", err)
+			}
+			if *info != *tt.want {
+				t.Errorf("VerifyToken() = %+v, want %+v", info, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyTokenUnknownKey(t *testing.T) {
+	provider := NewProvider(testClientID).WithKeySource(jwks.StaticKeySet{})
+
+	_, err := provider.VerifyToken(context.Background(), &dto.LoginOAuth_Payload{
+		IdToken: signToken(t, newTestKey(t), validClaims()),
+		Nonce:   testNonce,
+	})
+	if err == nil || !strings.Contains(err.Error(), "unknown key id") {
+		t.Errorf("VerifyToken() error = %v, want unknown key id error", err)
+	}
+}
@@ -0,0 +1,196 @@
+package facebook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/konsultin/project-goes-here/dto"
+)
+
+const (
+	graphBaseURL = "https://graph.facebook.com/v19.0"
+
+	// tokenTypeUser is the debug_token type of user access tokens, app and page tokens do not belong to a user
+	tokenTypeUser = "USER"
+)
+
+// Provider implements Facebook Login authentication
+type Provider struct {
+	appID      string
+	appSecret  string
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewProvider creates a new Facebook Login provider
+func NewProvider(appID string, appSecret string) *Provider {
+	return &Provider{
+		appID:     appID,
+		appSecret: appSecret,
+		baseURL:   graphBaseURL,
+		httpClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+// WithBaseURL returns a copy of the provider that calls the given Graph API base URL
+func (p *Provider) WithBaseURL(baseURL string) *Provider {
+	newP := *p
+	newP.baseURL = baseURL
+	return &newP
+}
+
+// DebugTokenInfo represents the response from Graph API debug_token endpoint
+type DebugTokenInfo struct {
+	Data struct {
+		AppId     string `json:"app_id"`     // App the token was issued for
+		Type      string `json:"type"`       // Token type, USER for user access token
+		IsValid   bool   `json:"is_valid"`   // Whether token is still valid
+		UserId    string `json:"user_id"`    // App-scoped user ID
+		ExpiresAt int64  `json:"expires_at"` // Expiration
+	} `json:"data"`
+	Error *GraphError `json:"error"`
+}
+
+// MeInfo represents the response from Graph API /me endpoint
+type MeInfo struct {
+	Id      string `json:"id"`
+	Name    string `json:"name"`
+	Email   string `json:"email"`
+	Picture struct {
+		Data struct {
+			Url string `json:"url"`
+		} `json:"data"`
+	} `json:"picture"`
+	Error *GraphError `json:"error"`
+}
+
+// GraphError represents an error returned by the Graph API
+type GraphError struct {
+	Message string `json:"message"`
+	Type    string `json:"type"`
+	Code    int    `json:"code"`
+}
+
+// VerifyToken verifies Facebook user access token and returns user info
+func (p *Provider) VerifyToken(ctx context.Context, payload *dto.LoginOAuth_Payload) (*dto.OAuthUserInfo, error) {
+	accessToken := payload.IdToken
+
+	// Inspect token using app access token
+	q := url.Values{}
+	q.Set("input_token", accessToken)
+	q.Set("access_token", p.appID+"|"+p.appSecret)
+
+	var debugInfo DebugTokenInfo
+	if err := p.get(ctx, "/debug_token", q, &debugInfo); err != nil {
+		return nil, err
+	}
+
+	// Check for error in response
+	if debugInfo.Error != nil {
+		return nil, fmt.Errorf("invalid token: %s", debugInfo.Error.Message)
+	}
+
+	if !debugInfo.Data.IsValid {
+		return nil, fmt.Errorf("invalid token: token is not valid")
+	}
+
+	// Verify token was issued for our app
+	if debugInfo.Data.AppId != p.appID {
+		return nil, fmt.Errorf("invalid app id: expected %s, got %s", p.appID, debugInfo.Data.AppId)
+	}
+
+	if debugInfo.Data.Type != tokenTypeUser {
+		return nil, fmt.Errorf("invalid token type: expected %s, got %s", tokenTypeUser, debugInfo.Data.Type)
+	}
+
+	// Get user profile
+	q = url.Values{}
+	q.Set("fields", "id,name,email,picture.type(large)")
+	q.Set("access_token", accessToken)
+	q.Set("appsecret_proof", p.appSecretProof(accessToken))
+
+	var me MeInfo
+	if err := p.get(ctx, "/me", q, &me); err != nil {
+		return nil, err
+	}
+
+	if me.Error != nil {
+		return nil, fmt.Errorf("failed to get user profile: %s", me.Error.Message)
+	}
+
+	// Verify profile belongs to token owner
+	if me.Id != debugInfo.Data.UserId {
+		return nil, fmt.Errorf("invalid user id: expected %s, got %s", debugInfo.Data.UserId, me.Id)
+	}
+
+	return &dto.OAuthUserInfo{
+		ProviderId: me.Id,
+		Email:      me.Email,
+		Name:       me.Name,
+		Picture:    me.Picture.Data.Url,
+		// Graph API does not assert the email has been verified, so it is never used to link accounts
+		EmailVerified: false,
+	}, nil
+}
+
+func (p *Provider) get(ctx context.Context, path string, query url.Values, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.baseURL+path+"?"+query.Encode(), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to verify token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	// Graph API answers errors with a non-2xx status, whatever the body can be decoded into
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var errBody struct {
+			Error *GraphError `json:"error"`
+		}
+		if json.Unmarshal(body, &errBody) == nil && errBody.Error != nil {
+			return fmt.Errorf("failed to verify token: unexpected status %d: %s", resp.StatusCode, errBody.Error.Message)
+		}
+		return fmt.Errorf("failed to verify token: unexpected status %d", resp.StatusCode)
+	}
+
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return nil
+}
+
+// appSecretProof signs the access token with app secret as required by Graph API
+func (p *Provider) appSecretProof(accessToken string) string {
+	mac := hmac.New(sha256.New, []byte(p.appSecret))
+	mac.Write([]byte(accessToken))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// GetProviderName returns the provider name
+func (p *Provider) GetProviderName() string {
+	return "facebook"
+}
+
+// GetProviderId returns the provider enum value
+func (p *Provider) GetProviderId() dto.AuthProvider_Enum {
+	return dto.AuthProvider_FACEBOOK
+}
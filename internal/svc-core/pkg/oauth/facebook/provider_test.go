@@ -0,0 +1,127 @@
+package facebook
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/konsultin/project-goes-here/dto"
+)
+
+const (
+	testAppID       = "app-id"
+	testAppSecret   = "app-secret"
+	testAccessToken = "user-access-token"
+)
+
+// graphResponse is a response of a fake Graph API endpoint
+type graphResponse struct {
+	status int
+	body   any
+}
+
+func newGraphServer(t *testing.T, debugToken, me graphResponse) *httptest.Server {
+	t.Helper()
+
+	write := func(w http.ResponseWriter, res graphResponse) {
+		w.Header().Set("Content-Type", "application/json")
+		if res.status != 0 {
+			w.WriteHeader(res.status)
+		}
+		_ = json.NewEncoder(w).Encode(res.body)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug_token", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("access_token"); got != testAppID+"|"+testAppSecret {
+			t.Errorf("debug_token access_token = %q, want app access token", got)
+		}
+		write(w, debugToken)
+	})
+	mux.HandleFunc("/me", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("access_token") != testAccessToken || q.Get("appsecret_proof") == "" {
+			t.Errorf("me query = %v, want user access token with appsecret_proof", q)
+		}
+		write(w, me)
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func debugTokenBody(appID, tokenType string, valid bool) map[string]any {
+	return map[string]any{
+		"data": map[string]any{
+			"app_id":   appID,
+			"type":     tokenType,
+			"is_valid": valid,
+			"user_id":  "10001",
+		},
+	}
+}
+
+func TestVerifyToken(t *testing.T) {
+	validDebugToken := graphResponse{body: debugTokenBody(testAppID, tokenTypeUser, true)}
+	validMe := graphResponse{body: map[string]any{
+		"id":    "10001",
+		"name":  "Jane Doe",
+		"email": "jane@example.com",
+		"picture": map[string]any{
+			"data": map[string]any{"url": "https://example.com/jane.jpg"},
+		},
+	}}
+	graphError := map[string]any{
+		"error": map[string]any{"message": "Invalid OAuth access token.", "type": "OAuthException", "code": 190},
+	}
+
+	tests := []struct {
+		name       string
+		debugToken graphResponse
+		me         graphResponse
+		wantErr    string
+	}{
+		{name: "valid", debugToken: validDebugToken, me: validMe},
+		{name: "invalid token", debugToken: graphResponse{body: debugTokenBody(testAppID, tokenTypeUser, false)}, me: validMe, wantErr: "token is not valid"},
+		{name: "app id mismatch", debugToken: graphResponse{body: debugTokenBody("other-app", tokenTypeUser, true)}, me: validMe, wantErr: "invalid app id"},
+		{name: "page token", debugToken: graphResponse{body: debugTokenBody(testAppID, "PAGE", true)}, me: validMe, wantErr: "invalid token type"},
+		{name: "app token", debugToken: graphResponse{body: debugTokenBody(testAppID, "APP", true)}, me: validMe, wantErr: "invalid token type"},
+		{name: "error of debug token", debugToken: graphResponse{body: graphError}, me: validMe, wantErr: "Invalid OAuth access token"},
+		{name: "non-2xx of debug token", debugToken: graphResponse{status: http.StatusBadRequest, body: graphError}, me: validMe, wantErr: "unexpected status 400"},
+		{name: "non-2xx without error body", debugToken: graphResponse{status: http.StatusInternalServerError, body: validDebugToken.body}, me: validMe, wantErr: "unexpected status 500"},
+		{name: "non-2xx of profile", debugToken: validDebugToken, me: graphResponse{status: http.StatusForbidden, body: validMe.body}, wantErr: "unexpected status 403"},
+		{name: "profile of other user", debugToken: validDebugToken, me: graphResponse{body: map[string]any{"id": "10002"}}, wantErr: "invalid user id"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newGraphServer(t, tt.debugToken, tt.me)
+			provider := NewProvider(testAppID, testAppSecret).WithBaseURL(server.URL)
+
+			info, err := provider.VerifyToken(context.Background(), &dto.LoginOAuth_Payload{IdToken: testAccessToken})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("VerifyToken() error = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("VerifyToken() error = %v", err)
+			}
+
+			want := dto.OAuthUserInfo{
+				ProviderId: "10001",
+				Email:      "jane@example.com",
+				Name:       "Jane Doe",
+				Picture:    "https://example.com/jane.jpg",
+			}
+			if *info != want {
+				t.Errorf("VerifyToken() = %+v, want %+v", info, want)
+			}
+		})
+	}
+}
@@ -2,106 +2,120 @@ package google
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
+	"strings"
 	"time"
 
+	"github.com/golang-jwt/jwt/v4"
 	"github.com/konsultin/project-goes-here/dto"
+	"github.com/konsultin/project-goes-here/internal/svc-core/pkg/oauth/jwks"
+	"github.com/konsultin/project-goes-here/internal/svc-core/pkg/valk"
 )
 
 const (
-	tokenInfoURL = "https://oauth2.googleapis.com/tokeninfo?id_token="
+	keysURL = "https://www.googleapis.com/oauth2/v3/certs"
 )
 
+// defaultKeySource is shared by providers so Google public keys are cached across requests
+var defaultKeySource jwks.KeySource = jwks.NewRemoteKeySet(keysURL)
+
+// issuers are the accepted values of Google ID token iss claim
+var issuers = []string{"accounts.google.com", "https://accounts.google.com"}
+
 // Provider implements Google OAuth authentication
 type Provider struct {
-	clientID   string
-	httpClient *http.Client
+	clientID      string
+	hostedDomains []string
+	keySource     jwks.KeySource
 }
 
 // NewProvider creates a new Google OAuth provider
 func NewProvider(clientID string) *Provider {
 	return &Provider{
-		clientID: clientID,
-		httpClient: &http.Client{
-			Timeout: 10 * time.Second,
-		},
+		clientID:  clientID,
+		keySource: defaultKeySource,
+	}
+}
+
+// WithHostedDomains returns a copy of the provider that only accepts Google Workspace accounts of the given domains
+func (p *Provider) WithHostedDomains(domains []string) *Provider {
+	newP := *p
+	newP.hostedDomains = nil
+	for _, domain := range domains {
+		if domain = strings.ToLower(strings.TrimSpace(domain)); domain != "" {
+			newP.hostedDomains = append(newP.hostedDomains, domain)
+		}
 	}
+	return &newP
 }
 
-// UserInfo represents user info from Google
-type UserInfo struct {
-	ProviderId    string // Google user ID (sub)
-	Email         string
-	Name          string
-	Picture       string
-	EmailVerified bool
+// WithKeySource returns a copy of the provider that resolves signing keys from the given source
+func (p *Provider) WithKeySource(keySource jwks.KeySource) *Provider {
+	newP := *p
+	newP.keySource = keySource
+	return &newP
 }
 
-// TokenInfo represents the response from Google's tokeninfo endpoint
-type TokenInfo struct {
-	Iss           string `json:"iss"`            // Issuer
+// IdTokenClaims represents the claims of Google ID token
+type IdTokenClaims struct {
+	jwt.RegisteredClaims
 	Azp           string `json:"azp"`            // Authorized party
-	Aud           string `json:"aud"`            // Audience (client ID)
-	Sub           string `json:"sub"`            // Subject (Google user ID)
 	Email         string `json:"email"`          // User's email
-	EmailVerified string `json:"email_verified"` // "true" or "false"
+	EmailVerified bool   `json:"email_verified"` // Whether Google verified the email
 	Name          string `json:"name"`           // User's full name
 	Picture       string `json:"picture"`        // Profile picture URL
 	GivenName     string `json:"given_name"`     // First name
 	FamilyName    string `json:"family_name"`    // Last name
-	Iat           string `json:"iat"`            // Issued at
-	Exp           string `json:"exp"`            // Expiration
-	Error         string `json:"error"`          // Error message if any
+	Hd            string `json:"hd"`             // Hosted domain of Google Workspace account
 }
 
-// VerifyToken verifies Google ID token and returns user info
-func (p *Provider) VerifyToken(ctx context.Context, idToken string) (*UserInfo, error) {
-	// Call Google's tokeninfo endpoint
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, tokenInfoURL+idToken, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+// VerifyToken verifies Google ID token locally against Google's public keys and returns user info
+func (p *Provider) VerifyToken(ctx context.Context, payload *dto.LoginOAuth_Payload) (*dto.OAuthUserInfo, error) {
+	keyFunc := func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		kid, _ := token.Header["kid"].(string)
+		return p.keySource.GetKey(ctx, kid)
 	}
 
-	resp, err := p.httpClient.Do(req)
+	// Parse validates exp, iat and nbf claims only when they are present
+	var claims IdTokenClaims
+	_, err := jwt.ParseWithClaims(payload.IdToken, &claims, keyFunc)
 	if err != nil {
-		return nil, fmt.Errorf("failed to verify token: %w", err)
+		return nil, fmt.Errorf("invalid token: %w", err)
 	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+	// Verify expiration, it is required
+	if !claims.VerifyExpiresAt(time.Now(), true) {
+		return nil, fmt.Errorf("invalid token: token is expired or has no expiration")
 	}
 
-	var tokenInfo TokenInfo
-	if err := json.Unmarshal(body, &tokenInfo); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+	// Verify audience matches our client ID
+	if !claims.VerifyAudience(p.clientID, true) {
+		return nil, fmt.Errorf("invalid audience: expected %s, got %s", p.clientID, strings.Join(claims.Audience, ","))
 	}
 
-	// Check for error in response
-	if tokenInfo.Error != "" {
-		return nil, fmt.Errorf("invalid token: %s", tokenInfo.Error)
+	// Verify issuer
+	if !valk.InArrayString(claims.Issuer, issuers) {
+		return nil, fmt.Errorf("invalid issuer: %s", claims.Issuer)
 	}
 
-	// Verify audience matches our client ID
-	if tokenInfo.Aud != p.clientID {
-		return nil, fmt.Errorf("invalid audience: expected %s, got %s", p.clientID, tokenInfo.Aud)
+	// Verify hosted domain when restricted
+	if len(p.hostedDomains) > 0 && !valk.InArrayString(strings.ToLower(claims.Hd), p.hostedDomains) {
+		return nil, fmt.Errorf("invalid hosted domain: %s", claims.Hd)
 	}
 
-	// Verify issuer
-	if tokenInfo.Iss != "accounts.google.com" && tokenInfo.Iss != "https://accounts.google.com" {
-		return nil, fmt.Errorf("invalid issuer: %s", tokenInfo.Iss)
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("invalid token: missing subject")
 	}
 
-	return &UserInfo{
-		ProviderId:    tokenInfo.Sub,
-		Email:         tokenInfo.Email,
-		Name:          tokenInfo.Name,
-		Picture:       tokenInfo.Picture,
-		EmailVerified: tokenInfo.EmailVerified == "true",
+	return &dto.OAuthUserInfo{
+		ProviderId:    claims.Subject,
+		Email:         strings.ToLower(claims.Email),
+		Name:          claims.Name,
+		Picture:       claims.Picture,
+		EmailVerified: claims.EmailVerified,
 	}, nil
 }
 
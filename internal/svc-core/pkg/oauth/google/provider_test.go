@@ -0,0 +1,137 @@
+package google
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/konsultin/project-goes-here/dto"
+	"github.com/konsultin/project-goes-here/internal/svc-core/pkg/oauth/jwks"
+)
+
+const (
+	testClientID = "client-id.apps.googleusercontent.com"
+	testKid      = "test-key"
+)
+
+func newTestKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	return key
+}
+
+func validClaims() *IdTokenClaims {
+	now := time.Now()
+	return &IdTokenClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    "https://accounts.google.com",
+			Subject:   "1234567890",
+			Audience:  jwt.ClaimStrings{testClientID},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+		},
+		Email:         "Jane@Example.com",
+		EmailVerified: true,
+		Name:          "Jane Doe",
+		Hd:            "example.com",
+	}
+}
+
+func signToken(t *testing.T, key *rsa.PrivateKey, kid string, claims *IdTokenClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+	return signed
+}
+
+func TestVerifyToken(t *testing.T) {
+	key := newTestKey(t)
+	otherKey := newTestKey(t)
+
+	provider := NewProvider(testClientID).WithKeySource(jwks.StaticKeySet{testKid: &key.PublicKey})
+	restricted := provider.WithHostedDomains([]string{" Example.com "})
+
+	tests := []struct {
+		name     string
+		provider *Provider
+		kid      string
+		key      *rsa.PrivateKey
+		modify   func(c *IdTokenClaims)
+		wantErr  string
+	}{
+		{name: "valid"},
+		{name: "issuer without scheme", modify: func(c *IdTokenClaims) { c.Issuer = "accounts.google.com" }},
+		{name: "hosted domain", provider: restricted},
+		{name: "bad audience", modify: func(c *IdTokenClaims) { c.Audience = jwt.ClaimStrings{"other-client"} }, wantErr: "invalid audience"},
+		{name: "bad issuer", modify: func(c *IdTokenClaims) { c.Issuer = "https://evil.example.com" }, wantErr: "invalid issuer"},
+		{name: "expired", modify: func(c *IdTokenClaims) { c.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute)) }, wantErr: "invalid token"},
+		{name: "missing expiration", modify: func(c *IdTokenClaims) { c.ExpiresAt = nil }, wantErr: "invalid token"},
+		{name: "not valid yet", modify: func(c *IdTokenClaims) { c.NotBefore = jwt.NewNumericDate(time.Now().Add(time.Hour)) }, wantErr: "invalid token"},
+		{name: "missing subject", modify: func(c *IdTokenClaims) { c.Subject = "" }, wantErr: "missing subject"},
+		{name: "unknown key id", kid: "other-key", wantErr: "unknown key id"},
+		{name: "bad signature", key: otherKey, wantErr: "invalid token"},
+		{name: "other hosted domain", provider: restricted, modify: func(c *IdTokenClaims) { c.Hd = "other.com" }, wantErr: "invalid hosted domain"},
+		{name: "consumer account on hosted domain", provider: restricted, modify: func(c *IdTokenClaims) { c.Hd = "" }, wantErr: "invalid hosted domain"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, kid, signingKey := provider, testKid, key
+			if tt.provider != nil {
+				p = tt.provider
+			}
+			if tt.kid != "" {
+				kid = tt.kid
+			}
+			if tt.key != nil {
+				signingKey = tt.key
+			}
+
+			claims := validClaims()
+			if tt.modify != nil {
+				tt.modify(claims)
+			}
+
+			info, err := p.VerifyToken(context.Background(), &dto.LoginOAuth_Payload{
+				IdToken: signToken(t, signingKey, kid, claims),
+			})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("VerifyToken() error = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("VerifyToken() error = %v", err)
+			}
+			if info.ProviderId != "1234567890" || info.Email != "jane@example.com" || !info.EmailVerified {
+				t.Errorf("VerifyToken() = %+v, want user info of token", info)
+			}
+		})
+	}
+}
+
+func TestVerifyTokenUnexpectedSigningMethod(t *testing.T) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, validClaims())
+	token.Header["kid"] = testKid
+	signed, err := token.SignedString([]byte("secret"))
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+
+	provider := NewProvider(testClientID).WithKeySource(jwks.StaticKeySet{testKid: &newTestKey(t).PublicKey})
+	if _, err = provider.VerifyToken(context.Background(), &dto.LoginOAuth_Payload{IdToken: signed}); err == nil {
+		t.Errorf("VerifyToken() error = nil, want error for HS256 token")
+	}
+}
@@ -0,0 +1,241 @@
+package jwks
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultTTL         = 24 * time.Hour
+	minTTL             = 5 * time.Minute
+	minRefreshInterval = time.Minute
+	// refreshAhead is how long before expiry cached keys are refreshed in the background
+	refreshAhead = 5 * time.Minute
+)
+
+// KeySource resolves public keys by key id to verify JWT signatures
+type KeySource interface {
+	GetKey(ctx context.Context, kid string) (crypto.PublicKey, error)
+}
+
+// StaticKeySet is a fixed KeySource, useful for tests with locally signed tokens
+type StaticKeySet map[string]crypto.PublicKey
+
+// GetKey returns the key for the given key id
+func (s StaticKeySet) GetKey(_ context.Context, kid string) (crypto.PublicKey, error) {
+	key, ok := s[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key id: %s", kid)
+	}
+	return key, nil
+}
+
+// RemoteKeySet fetches a JSON Web Key Set from URL and caches the keys.
+// Cache lifetime follows the Cache-Control max-age of the response and keys are
+// refreshed in the background shortly before they expire
+type RemoteKeySet struct {
+	url        string
+	ttl        time.Duration
+	httpClient *http.Client
+
+	mu         sync.RWMutex
+	keys       map[string]crypto.PublicKey
+	expiresAt  time.Time
+	fetchedAt  time.Time
+	refreshing atomic.Bool
+}
+
+// NewRemoteKeySet creates a new cached key set for the given JWKS URL
+func NewRemoteKeySet(url string) *RemoteKeySet {
+	return &RemoteKeySet{
+		url: url,
+		ttl: defaultTTL,
+		httpClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+// GetKey returns the key for the given key id, refreshing the cache when expired or when key id is unknown
+func (s *RemoteKeySet) GetKey(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	s.mu.RLock()
+	key, ok := s.keys[kid]
+	expiresAt := s.expiresAt
+	s.mu.RUnlock()
+
+	now := time.Now()
+	expired := now.After(expiresAt)
+	if ok && !expired {
+		// Refresh ahead of expiry so requests never wait for provider
+		if now.Add(refreshAhead).After(expiresAt) {
+			s.refreshInBackground()
+		}
+		return key, nil
+	}
+
+	// Refresh keys, provider may have rotated its signing key
+	if err := s.refresh(ctx, !expired); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	key, ok = s.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key id: %s", kid)
+	}
+	return key, nil
+}
+
+func (s *RemoteKeySet) refresh(ctx context.Context, throttle bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Avoid hammering provider with unknown key ids
+	if throttle && time.Since(s.fetchedAt) < minRefreshInterval {
+		return nil
+	}
+
+	keys, ttl, err := s.fetch(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	s.keys = keys
+	s.fetchedAt = now
+	s.expiresAt = now.Add(ttl)
+
+	return nil
+}
+
+func (s *RemoteKeySet) refreshInBackground() {
+	if !s.refreshing.CompareAndSwap(false, true) {
+		return
+	}
+
+	go func() {
+		defer s.refreshing.Store(false)
+
+		ctx, cancel := context.WithTimeout(context.Background(), s.httpClient.Timeout)
+		defer cancel()
+
+		// Keep serving cached keys if refresh fails, GetKey retries once they expire
+		_ = s.refresh(ctx, true)
+	}()
+}
+
+func (s *RemoteKeySet) fetch(ctx context.Context) (map[string]crypto.PublicKey, time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to fetch jwks: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, 0, fmt.Errorf("failed to fetch jwks: unexpected status %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	keys, err := ParseKeySet(body)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return keys, cacheTTL(resp.Header.Get("Cache-Control"), s.ttl), nil
+}
+
+// cacheTTL returns max-age of Cache-Control header, or fallback when absent
+func cacheTTL(cacheControl string, fallback time.Duration) time.Duration {
+	for _, directive := range strings.Split(cacheControl, ",") {
+		name, value, found := strings.Cut(strings.TrimSpace(directive), "=")
+		if !found || !strings.EqualFold(name, "max-age") {
+			continue
+		}
+
+		seconds, err := strconv.Atoi(value)
+		if err != nil {
+			break
+		}
+
+		ttl := time.Duration(seconds) * time.Second
+		if ttl < minTTL {
+			return minTTL
+		}
+		return ttl
+	}
+	return fallback
+}
+
+// JSONWebKey represents a single key of a JSON Web Key Set
+type JSONWebKey struct {
+	Kty string `json:"kty"` // Key type
+	Kid string `json:"kid"` // Key ID
+	Use string `json:"use"` // Public key use
+	Alg string `json:"alg"` // Algorithm
+	N   string `json:"n"`   // RSA modulus
+	E   string `json:"e"`   // RSA exponent
+}
+
+// ParseKeySet parses a JSON Web Key Set document into public keys indexed by key id
+func ParseKeySet(data []byte) (map[string]crypto.PublicKey, error) {
+	var doc struct {
+		Keys []JSONWebKey `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse jwks: %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(doc.Keys))
+	for _, jwk := range doc.Keys {
+		if jwk.Kty != "RSA" || (jwk.Use != "" && jwk.Use != "sig") {
+			continue
+		}
+
+		key, err := parseRSAPublicKey(jwk)
+		if err != nil {
+			return nil, err
+		}
+		keys[jwk.Kid] = key
+	}
+
+	return keys, nil
+}
+
+func parseRSAPublicKey(jwk JSONWebKey) (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+	if err != nil {
+		return nil, fmt.Errorf("invalid modulus for key %s: %w", jwk.Kid, err)
+	}
+
+	e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+	if err != nil {
+		return nil, fmt.Errorf("invalid exponent for key %s: %w", jwk.Kid, err)
+	}
+
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(n),
+		E: int(new(big.Int).SetBytes(e).Int64()),
+	}, nil
+}
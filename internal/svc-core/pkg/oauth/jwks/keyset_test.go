@@ -0,0 +1,174 @@
+package jwks
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	return key
+}
+
+func toJSONWebKey(kid string, key *rsa.PublicKey) JSONWebKey {
+	return JSONWebKey{
+		Kty: "RSA",
+		Kid: kid,
+		Use: "sig",
+		Alg: "RS256",
+		N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+func encodeKeySet(t *testing.T, keys ...JSONWebKey) []byte {
+	t.Helper()
+	data, err := json.Marshal(map[string][]JSONWebKey{"keys": keys})
+	if err != nil {
+		t.Fatalf("encode jwks: %v", err)
+	}
+	return data
+}
+
+func TestParseKeySet(t *testing.T) {
+	key := newTestKey(t)
+
+	encryption := toJSONWebKey("enc", &key.PublicKey)
+	encryption.Use = "enc"
+	ec := JSONWebKey{Kty: "EC", Kid: "ec"}
+	noUse := toJSONWebKey("no-use", &key.PublicKey)
+	noUse.Use = ""
+
+	keys, err := ParseKeySet(encodeKeySet(t, toJSONWebKey("sig", &key.PublicKey), encryption, ec, noUse))
+	if err != nil {
+		t.Fatalf("ParseKeySet() error = %v", err)
+	}
+
+	if len(keys) != 2 {
+		t.Fatalf("ParseKeySet() = %d keys, want 2", len(keys))
+	}
+	for _, kid := range []string{"sig", "no-use"} {
+		got, ok := keys[kid].(*rsa.PublicKey)
+		if !ok || !got.Equal(&key.PublicKey) {
+			t.Errorf("ParseKeySet()[%s] = %v, want the RSA key", kid, keys[kid])
+		}
+	}
+}
+
+func TestParseKeySetInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{name: "malformed json", data: `{"keys": [`},
+		{name: "invalid modulus", data: `{"keys": [{"kty": "RSA", "kid": "a", "n": "!!", "e": "AQAB"}]}`},
+		{name: "invalid exponent", data: `{"keys": [{"kty": "RSA", "kid": "a", "n": "AQAB", "e": "!!"}]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseKeySet([]byte(tt.data)); err == nil {
+				t.Errorf("ParseKeySet() error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestCacheTTL(t *testing.T) {
+	tests := []struct {
+		cacheControl string
+		want         time.Duration
+	}{
+		{cacheControl: "", want: defaultTTL},
+		{cacheControl: "public, max-age=21600, must-revalidate", want: 6 * time.Hour},
+		{cacheControl: "Max-Age=3600", want: time.Hour},
+		{cacheControl: "max-age=10", want: minTTL},
+		{cacheControl: "max-age=abc", want: defaultTTL},
+		{cacheControl: "no-cache", want: defaultTTL},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.cacheControl, func(t *testing.T) {
+			if got := cacheTTL(tt.cacheControl, defaultTTL); got != tt.want {
+				t.Errorf("cacheTTL() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStaticKeySet(t *testing.T) {
+	key := newTestKey(t)
+	s := StaticKeySet{"a": &key.PublicKey}
+
+	if got, err := s.GetKey(context.Background(), "a"); err != nil || got != &key.PublicKey {
+		t.Errorf("GetKey() = %v, %v, want the key", got, err)
+	}
+	if _, err := s.GetKey(context.Background(), "b"); err == nil {
+		t.Errorf("GetKey() of unknown key id error = nil, want error")
+	}
+}
+
+func TestRemoteKeySet(t *testing.T) {
+	key := newTestKey(t)
+
+	var fetches atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		w.Header().Set("Cache-Control", "public, max-age=3600")
+		_, _ = w.Write(encodeKeySet(t, toJSONWebKey("a", &key.PublicKey)))
+	}))
+	defer server.Close()
+
+	s := NewRemoteKeySet(server.URL)
+	ctx := context.Background()
+
+	got, err := s.GetKey(ctx, "a")
+	if err != nil {
+		t.Fatalf("GetKey() error = %v", err)
+	}
+	if !got.(*rsa.PublicKey).Equal(&key.PublicKey) {
+		t.Errorf("GetKey() = %v, want the served key", got)
+	}
+
+	// Cached key does not fetch again
+	if _, err = s.GetKey(ctx, "a"); err != nil {
+		t.Fatalf("GetKey() of cached key error = %v", err)
+	}
+	if n := fetches.Load(); n != 1 {
+		t.Errorf("fetches after cached key = %d, want 1", n)
+	}
+
+	// Unknown key id refreshes at most once per interval
+	for i := 0; i < 3; i++ {
+		if _, err = s.GetKey(ctx, "b"); err == nil {
+			t.Fatalf("GetKey() of unknown key id error = nil, want error")
+		}
+	}
+	if n := fetches.Load(); n != 1 {
+		t.Errorf("fetches after unknown key ids = %d, want 1", n)
+	}
+}
+
+func TestRemoteKeySetUnexpectedStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	if _, err := NewRemoteKeySet(server.URL).GetKey(context.Background(), "a"); err == nil {
+		t.Errorf("GetKey() error = nil, want error for unexpected status")
+	}
+}
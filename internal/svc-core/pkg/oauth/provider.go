@@ -0,0 +1,64 @@
+package oauth
+
+import (
+	"context"
+	"sync"
+
+	"github.com/konsultin/project-goes-here/dto"
+)
+
+// Provider verifies tokens issued by an external identity provider
+type Provider interface {
+	// GetProviderName returns the provider name
+	GetProviderName() string
+	// GetProviderId returns the provider enum value
+	GetProviderId() dto.AuthProvider_Enum
+	// VerifyToken verifies the token in login payload and returns user info
+	VerifyToken(ctx context.Context, payload *dto.LoginOAuth_Payload) (*dto.OAuthUserInfo, error)
+}
+
+// Registry holds identity providers indexed by auth provider
+type Registry struct {
+	mu        sync.RWMutex
+	providers map[dto.AuthProvider_Enum]Provider
+}
+
+// NewRegistry creates a new registry with the given providers
+func NewRegistry(providers ...Provider) *Registry {
+	r := &Registry{
+		providers: make(map[dto.AuthProvider_Enum]Provider),
+	}
+	for _, p := range providers {
+		r.Register(p)
+	}
+	return r
+}
+
+// Register adds provider to registry, replacing any provider with the same id
+func (r *Registry) Register(p Provider) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.providers[p.GetProviderId()] = p
+}
+
+// Get returns the provider registered for the given auth provider
+func (r *Registry) Get(id dto.AuthProvider_Enum) (Provider, bool) {
+	if r == nil {
+		return nil, false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	p, ok := r.providers[id]
+	return p, ok
+}
+
+// Names returns names of registered providers
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var names []string
+	for _, p := range r.providers {
+		names = append(names, p.GetProviderName())
+	}
+	return names
+}
@@ -0,0 +1,127 @@
+package pwpolicy
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+)
+
+// falsePositiveRate of the bloom filter, a rejected password has this chance of not being breached at all
+const falsePositiveRate = 0.001
+
+// BreachedList is a bloom filter of breached passwords, loaded from a local file so no network is needed.
+// Passwords are keyed by their SHA-1 digest, compatible with the Have I Been Pwned password dump
+type BreachedList struct {
+	count  uint64
+	bits   []uint64
+	size   uint64
+	hashes uint64
+}
+
+// LoadBreachedList reads a file with a breached password per line. A line is either a password in plain text,
+// or a hex SHA-1 digest of the password optionally followed by ":<count>". Empty lines and lines starting with # are skipped
+func LoadBreachedList(path string) (*BreachedList, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("pwpolicy: open %s: %w", path, err)
+	}
+	defer f.Close()
+
+	// First pass sizes the filter
+	var n uint64
+	err = scanDigests(f, func([sha1.Size]byte) { n++ })
+	if err != nil {
+		return nil, fmt.Errorf("pwpolicy: read %s: %w", path, err)
+	}
+
+	l := newBreachedList(n)
+
+	if _, err = f.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("pwpolicy: read %s: %w", path, err)
+	}
+	err = scanDigests(f, l.add)
+	if err != nil {
+		return nil, fmt.Errorf("pwpolicy: read %s: %w", path, err)
+	}
+
+	return l, nil
+}
+
+// Count returns the number of passwords loaded
+func (l *BreachedList) Count() uint64 {
+	return l.count
+}
+
+// Contains checks if the password may be in the list
+func (l *BreachedList) Contains(password string) bool {
+	digest := sha1.Sum([]byte(password))
+	h1, h2 := splitDigest(digest)
+	for i := uint64(0); i < l.hashes; i++ {
+		bit := (h1 + i*h2) % l.size
+		if l.bits[bit/64]&(1<<(bit%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func newBreachedList(n uint64) *BreachedList {
+	// Optimal bloom filter size and number of hash functions for n items
+	items := math.Max(1, float64(n))
+	size := uint64(math.Ceil(-items * math.Log(falsePositiveRate) / (math.Ln2 * math.Ln2)))
+	hashes := uint64(math.Max(1, math.Round(float64(size)/items*math.Ln2)))
+
+	return &BreachedList{
+		count:  n,
+		bits:   make([]uint64, (size+63)/64),
+		size:   size,
+		hashes: hashes,
+	}
+}
+
+func (l *BreachedList) add(digest [sha1.Size]byte) {
+	h1, h2 := splitDigest(digest)
+	for i := uint64(0); i < l.hashes; i++ {
+		bit := (h1 + i*h2) % l.size
+		l.bits[bit/64] |= 1 << (bit % 64)
+	}
+}
+
+// splitDigest derives the two hashes of double hashing from the digest, which is already uniformly distributed
+func splitDigest(digest [sha1.Size]byte) (uint64, uint64) {
+	return binary.BigEndian.Uint64(digest[:8]), binary.BigEndian.Uint64(digest[8:16]) | 1
+}
+
+func scanDigests(r io.Reader, fn func([sha1.Size]byte)) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fn(parseDigest(line))
+	}
+	return scanner.Err()
+}
+
+func parseDigest(line string) [sha1.Size]byte {
+	hexDigest := line
+	if i := strings.IndexByte(line, ':'); i == sha1.Size*2 {
+		hexDigest = line[:i]
+	}
+
+	var digest [sha1.Size]byte
+	if len(hexDigest) == sha1.Size*2 {
+		if _, err := hex.Decode(digest[:], []byte(hexDigest)); err == nil {
+			return digest
+		}
+	}
+
+	return sha1.Sum([]byte(line))
+}
@@ -0,0 +1,128 @@
+package pwpolicy
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeBreachedFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "breached.txt")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("write breached file: %v", err)
+	}
+	return path
+}
+
+func TestParseDigest(t *testing.T) {
+	// SHA-1 of "password"
+	const digest = "5baa61e4c9b93f3f0682250b6cf8331b7ee68fd8"
+
+	tests := []struct {
+		name string
+		line string
+		want string
+	}{
+		{name: "plain password", line: "password", want: digest},
+		{name: "digest", line: digest, want: digest},
+		{name: "uppercase digest", line: "5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8", want: digest},
+		{name: "digest with count", line: "5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8:3861493", want: digest},
+		{name: "password with colon", line: "pass:word", want: fmt.Sprintf("%x", sha1.Sum([]byte("pass:word")))},
+		{name: "not hex of digest length", line: "zbaa61e4c9b93f3f0682250b6cf8331b7ee68fd8", want: fmt.Sprintf("%x", sha1.Sum([]byte("zbaa61e4c9b93f3f0682250b6cf8331b7ee68fd8")))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseDigest(tt.line)
+			if hex.EncodeToString(got[:]) != tt.want {
+				t.Errorf("parseDigest() = %x, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadBreachedList(t *testing.T) {
+	path := writeBreachedFile(t, "# breached passwords\r\n"+
+		"123456\r\n"+
+		"\r\n"+
+		"5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8:3861493\r\n"+
+		"qwerty\n")
+
+	l, err := LoadBreachedList(path)
+	if err != nil {
+		t.Fatalf("LoadBreachedList() error = %v", err)
+	}
+	if l.Count() != 3 {
+		t.Errorf("Count() = %d, want 3", l.Count())
+	}
+
+	tests := []struct {
+		password string
+		want     bool
+	}{
+		{password: "123456", want: true},
+		{password: "password", want: true},
+		{password: "qwerty", want: true},
+		{password: "Tr0ub4dor&3x"},
+		{password: "# breached passwords"},
+		{password: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.password, func(t *testing.T) {
+			if got := l.Contains(tt.password); got != tt.want {
+				t.Errorf("Contains() = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadBreachedListMissingFile(t *testing.T) {
+	if _, err := LoadBreachedList(filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Errorf("LoadBreachedList() error = nil, want error")
+	}
+}
+
+func TestBreachedListFalsePositiveRate(t *testing.T) {
+	const n = 10000
+
+	l := newBreachedList(n)
+	for i := 0; i < n; i++ {
+		l.add(parseDigest(fmt.Sprintf("breached-%d", i)))
+	}
+
+	for i := 0; i < n; i++ {
+		if password := fmt.Sprintf("breached-%d", i); !l.Contains(password) {
+			t.Fatalf("Contains(%q) = false, bloom filter must not have false negatives", password)
+		}
+	}
+
+	var falsePositives int
+	for i := 0; i < n; i++ {
+		if l.Contains(fmt.Sprintf("other-%d", i)) {
+			falsePositives++
+		}
+	}
+
+	// Deterministic for these inputs, the bound leaves room above the configured rate
+	if rate := float64(falsePositives) / n; rate > falsePositiveRate*5 {
+		t.Errorf("false positive rate = %.4f, want about %.4f", rate, falsePositiveRate)
+	}
+}
+
+func TestEmptyBreachedList(t *testing.T) {
+	l, err := LoadBreachedList(writeBreachedFile(t, "# nothing yet\n"))
+	if err != nil {
+		t.Fatalf("LoadBreachedList() error = %v", err)
+	}
+	if l.Count() != 0 {
+		t.Errorf("Count() = %d, want 0", l.Count())
+	}
+	if l.Contains("password") {
+		t.Errorf("Contains() = true, want false for empty list")
+	}
+}
@@ -0,0 +1,137 @@
+package pwpolicy
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Rule names of violations
+const (
+	RuleMinLength    = "min"
+	RuleLowercase    = "lowercase"
+	RuleUppercase    = "uppercase"
+	RuleDigit        = "digit"
+	RuleSymbol       = "symbol"
+	RulePersonalInfo = "personal_info"
+	RuleBreached     = "breached"
+)
+
+// minPersonalTokenLength ignores short name parts, e.g. "Al", that would reject too many passwords
+const minPersonalTokenLength = 3
+
+// Rules configures the checks of a password policy
+type Rules struct {
+	MinLength          int
+	RequireLowercase   bool
+	RequireUppercase   bool
+	RequireDigit       bool
+	RequireSymbol      bool
+	RejectPersonalInfo bool
+}
+
+// Violation is a rule the password does not satisfy, Message completes a sentence starting with the field name
+type Violation struct {
+	Rule    string
+	Message string
+}
+
+// PersonalInfo is the user information a password must not contain
+type PersonalInfo struct {
+	FullName string
+	Email    string
+	Username string
+}
+
+// Policy checks passwords against rules and an optional breached password list
+type Policy struct {
+	rules    Rules
+	breached *BreachedList
+}
+
+// New creates a policy, breached may be nil to skip the breached password check
+func New(rules Rules, breached *BreachedList) *Policy {
+	return &Policy{rules: rules, breached: breached}
+}
+
+// Check returns every rule the password violates, empty if the password is acceptable
+func (p *Policy) Check(password string, info PersonalInfo) []Violation {
+	var violations []Violation
+
+	if utf8.RuneCountInString(password) < p.rules.MinLength {
+		violations = append(violations, Violation{
+			Rule:    RuleMinLength,
+			Message: fmt.Sprintf("must be at least %d characters", p.rules.MinLength),
+		})
+	}
+
+	var hasLower, hasUpper, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			hasSymbol = true
+		}
+	}
+
+	if p.rules.RequireLowercase && !hasLower {
+		violations = append(violations, Violation{Rule: RuleLowercase, Message: "must contain a lowercase letter"})
+	}
+	if p.rules.RequireUppercase && !hasUpper {
+		violations = append(violations, Violation{Rule: RuleUppercase, Message: "must contain an uppercase letter"})
+	}
+	if p.rules.RequireDigit && !hasDigit {
+		violations = append(violations, Violation{Rule: RuleDigit, Message: "must contain a digit"})
+	}
+	if p.rules.RequireSymbol && !hasSymbol {
+		violations = append(violations, Violation{Rule: RuleSymbol, Message: "must contain a symbol"})
+	}
+
+	if p.rules.RejectPersonalInfo && containsPersonalInfo(password, info) {
+		violations = append(violations, Violation{
+			Rule:    RulePersonalInfo,
+			Message: "must not contain your name, email or username",
+		})
+	}
+
+	if p.breached != nil && p.breached.Contains(password) {
+		violations = append(violations, Violation{
+			Rule:    RuleBreached,
+			Message: "has appeared in a data breach, choose a different one",
+		})
+	}
+
+	return violations
+}
+
+// containsPersonalInfo checks case-insensitively if the password contains a part of the user information
+func containsPersonalInfo(password string, info PersonalInfo) bool {
+	tokens := strings.FieldsFunc(info.FullName, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	tokens = append(tokens, info.Username)
+	if email := info.Email; email != "" {
+		tokens = append(tokens, email)
+		if at := strings.LastIndex(email, "@"); at > 0 {
+			tokens = append(tokens, email[:at])
+		}
+	}
+
+	password = strings.ToLower(password)
+	for _, token := range tokens {
+		if utf8.RuneCountInString(token) < minPersonalTokenLength {
+			continue
+		}
+		if strings.Contains(password, strings.ToLower(token)) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,78 @@
+package pwpolicy
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestPolicyCheck(t *testing.T) {
+	strict := Rules{
+		MinLength:          10,
+		RequireLowercase:   true,
+		RequireUppercase:   true,
+		RequireDigit:       true,
+		RequireSymbol:      true,
+		RejectPersonalInfo: true,
+	}
+	info := PersonalInfo{
+		FullName: "Jane Al Doe",
+		Email:    "jane.doe@example.com",
+		Username: "janed",
+	}
+
+	tests := []struct {
+		name     string
+		rules    Rules
+		password string
+		info     PersonalInfo
+		want     []string
+	}{
+		{name: "no rules", password: "a"},
+		{name: "satisfies every rule", rules: strict, password: "Tr0ub4dor&3x", info: info},
+		{name: "too short", rules: Rules{MinLength: 8}, password: "1234567", want: []string{RuleMinLength}},
+		{name: "min length counts characters", rules: Rules{MinLength: 4}, password: "ñàéü"},
+		{name: "empty", rules: strict, password: "", want: []string{RuleMinLength, RuleLowercase, RuleUppercase, RuleDigit, RuleSymbol}},
+		{name: "missing lowercase", rules: strict, password: "TR0UB4DOR&3X", want: []string{RuleLowercase}},
+		{name: "missing uppercase", rules: strict, password: "tr0ub4dor&3x", want: []string{RuleUppercase}},
+		{name: "missing digit", rules: strict, password: "Troubador&xx", want: []string{RuleDigit}},
+		{name: "missing symbol", rules: strict, password: "Tr0ub4dor33x", want: []string{RuleSymbol}},
+		{name: "space is a symbol", rules: strict, password: "Tr0ub4dor 3x"},
+		{name: "contains name", rules: strict, password: "Xy1!JANE-pass", info: info, want: []string{RulePersonalInfo}},
+		{name: "contains last name", rules: strict, password: "Xy1!doedoe", info: info, want: []string{RulePersonalInfo}},
+		{name: "short name part is ignored", rules: strict, password: "Xy1!al-pass", info: info},
+		{name: "contains username", rules: strict, password: "Xy1!janedrocks", info: info, want: []string{RulePersonalInfo}},
+		{name: "contains email local part", rules: strict, password: "Xy1!Jane.Doe99", info: info, want: []string{RulePersonalInfo}},
+		{name: "personal info allowed", rules: Rules{}, password: "janedoe", info: info},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			violations := New(tt.rules, nil).Check(tt.password, tt.info)
+
+			var got []string
+			for _, v := range violations {
+				if v.Message == "" {
+					t.Errorf("violation %s has no message", v.Rule)
+				}
+				got = append(got, v.Rule)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Check() rules = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolicyCheckBreached(t *testing.T) {
+	breached := newBreachedList(1)
+	breached.add(parseDigest("password"))
+
+	policy := New(Rules{}, breached)
+
+	if got := policy.Check("password", PersonalInfo{}); len(got) != 1 || got[0].Rule != RuleBreached {
+		t.Errorf("Check() of breached password = %v, want %s violation", got, RuleBreached)
+	}
+	if got := policy.Check("Tr0ub4dor&3x", PersonalInfo{}); len(got) != 0 {
+		t.Errorf("Check() of other password = %v, want no violation", got)
+	}
+}
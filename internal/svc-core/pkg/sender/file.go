@@ -0,0 +1,52 @@
+package sender
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// FileSender appends messages to a file as JSON lines, for development and tests
+type FileSender struct {
+	mu   sync.Mutex
+	path string
+}
+
+type fileMessage struct {
+	*Message
+	SentAt int64 `json:"sentAt"`
+}
+
+// NewFileSender creates a sender that appends messages to the file
+func NewFileSender(path string) *FileSender {
+	return &FileSender{path: path}
+}
+
+func (s *FileSender) GetSenderName() string {
+	return "file"
+}
+
+func (s *FileSender) Send(_ context.Context, msg *Message) error {
+	line, err := json.Marshal(fileMessage{Message: msg, SentAt: time.Now().Unix()})
+	if err != nil {
+		return fmt.Errorf("sender: marshal message: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("sender: open %s: %w", s.path, err)
+	}
+	defer f.Close()
+
+	if _, err = f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("sender: write %s: %w", s.path, err)
+	}
+
+	return nil
+}
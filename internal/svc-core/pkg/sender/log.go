@@ -0,0 +1,26 @@
+package sender
+
+import (
+	"context"
+
+	"github.com/go-konsultin/logk"
+)
+
+// LogSender writes messages to application log instead of delivering them, for development only
+type LogSender struct {
+	log logk.Logger
+}
+
+// NewLogSender creates a sender that writes messages to the logger
+func NewLogSender(log logk.Logger) *LogSender {
+	return &LogSender{log: log}
+}
+
+func (s *LogSender) GetSenderName() string {
+	return "log"
+}
+
+func (s *LogSender) Send(_ context.Context, msg *Message) error {
+	s.log.Infof("Message sent. Channel=%s Recipient=%s Subject=%s Body=%s", msg.Channel, msg.Recipient, msg.Subject, msg.Body)
+	return nil
+}
@@ -0,0 +1,61 @@
+package sender
+
+import (
+	"context"
+	"sync"
+)
+
+// MemorySender keeps messages in memory instead of delivering them, for tests
+type MemorySender struct {
+	mu       sync.Mutex
+	messages []*Message
+}
+
+// NewMemorySender creates a sender that keeps messages in memory
+func NewMemorySender() *MemorySender {
+	return &MemorySender{}
+}
+
+func (s *MemorySender) GetSenderName() string {
+	return "memory"
+}
+
+func (s *MemorySender) Send(_ context.Context, msg *Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m := *msg
+	s.messages = append(s.messages, &m)
+
+	return nil
+}
+
+// Messages returns sent messages in order
+func (s *MemorySender) Messages() []*Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]*Message(nil), s.messages...)
+}
+
+// Last returns the latest message sent to the recipient, nil if there is none
+func (s *MemorySender) Last(recipient string) *Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := len(s.messages) - 1; i >= 0; i-- {
+		if s.messages[i].Recipient == recipient {
+			return s.messages[i]
+		}
+	}
+
+	return nil
+}
+
+// Reset removes sent messages
+func (s *MemorySender) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.messages = nil
+}
@@ -0,0 +1,44 @@
+package sender
+
+import (
+	"context"
+	"slices"
+	"strings"
+)
+
+// Router delivers each message with the sender registered for its channel, e.g. an SMS gateway for phone numbers
+// and a mail service for emails, falling back to the default sender
+type Router struct {
+	fallback Sender
+	channels map[Channel]Sender
+}
+
+// NewRouter creates a router delivering through fallback unless a sender is registered for the channel
+func NewRouter(fallback Sender) *Router {
+	return &Router{
+		fallback: fallback,
+		channels: make(map[Channel]Sender),
+	}
+}
+
+// WithChannel registers the sender of a channel
+func (r *Router) WithChannel(channel Channel, sender Sender) *Router {
+	r.channels[channel] = sender
+	return r
+}
+
+func (r *Router) GetSenderName() string {
+	var names []string
+	for channel, sender := range r.channels {
+		names = append(names, string(channel)+"="+sender.GetSenderName())
+	}
+	slices.Sort(names)
+	return strings.Join(append([]string{r.fallback.GetSenderName()}, names...), ",")
+}
+
+func (r *Router) Send(ctx context.Context, msg *Message) error {
+	if sender, ok := r.channels[msg.Channel]; ok {
+		return sender.Send(ctx, msg)
+	}
+	return r.fallback.Send(ctx, msg)
+}
@@ -0,0 +1,38 @@
+package sender
+
+import (
+	"context"
+	"strings"
+)
+
+// Channel is the medium a message is delivered through
+type Channel string
+
+const (
+	ChannelEmail Channel = "email"
+	ChannelSms   Channel = "sms"
+)
+
+// Message is a notification addressed to a single recipient
+type Message struct {
+	Channel   Channel `json:"channel"`
+	Recipient string  `json:"recipient"`
+	Subject   string  `json:"subject,omitempty"`
+	Body      string  `json:"body"`
+}
+
+// Sender delivers messages to users, e.g. password reset links or one-time codes
+type Sender interface {
+	// GetSenderName returns the sender name
+	GetSenderName() string
+	// Send delivers the message
+	Send(ctx context.Context, msg *Message) error
+}
+
+// ChannelOf returns the channel to reach a recipient, email address or phone number
+func ChannelOf(recipient string) Channel {
+	if strings.Contains(recipient, "@") {
+		return ChannelEmail
+	}
+	return ChannelSms
+}
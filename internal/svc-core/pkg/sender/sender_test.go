@@ -0,0 +1,115 @@
+package sender
+
+import (
+	"context"
+	"testing"
+)
+
+func TestChannelOf(t *testing.T) {
+	tests := []struct {
+		recipient string
+		want      Channel
+	}{
+		{recipient: "jane@example.com", want: ChannelEmail},
+		{recipient: "+6281234567890", want: ChannelSms},
+		{recipient: "081234567890", want: ChannelSms},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.recipient, func(t *testing.T) {
+			if got := ChannelOf(tt.recipient); got != tt.want {
+				t.Errorf("ChannelOf() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMemorySender(t *testing.T) {
+	s := NewMemorySender()
+	ctx := context.Background()
+
+	msg := &Message{Channel: ChannelEmail, Recipient: "jane@example.com", Body: "first"}
+	if err := s.Send(ctx, msg); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	// Sent message is a copy, changes of the caller are not kept
+	msg.Body = "changed"
+
+	_ = s.Send(ctx, &Message{Channel: ChannelSms, Recipient: "+6281234567890", Body: "second"})
+	_ = s.Send(ctx, &Message{Channel: ChannelEmail, Recipient: "jane@example.com", Body: "third"})
+
+	if got := len(s.Messages()); got != 3 {
+		t.Fatalf("Messages() length = %d, want 3", got)
+	}
+	if got := s.Messages()[0].Body; got != "first" {
+		t.Errorf("Messages()[0].Body = %q, want %q", got, "first")
+	}
+
+	if got := s.Last("jane@example.com"); got == nil || got.Body != "third" {
+		t.Errorf("Last() = %+v, want message with body %q", got, "third")
+	}
+	if got := s.Last("john@example.com"); got != nil {
+		t.Errorf("Last() of unknown recipient = %+v, want nil", got)
+	}
+
+	s.Reset()
+	if got := len(s.Messages()); got != 0 {
+		t.Errorf("Messages() length after Reset() = %d, want 0", got)
+	}
+}
+
+func TestRouter(t *testing.T) {
+	tests := []struct {
+		name      string
+		recipient string
+		withSms   bool
+		wantSms   bool
+	}{
+		{name: "email without channel sender", recipient: "jane@example.com"},
+		{name: "sms without channel sender", recipient: "+6281234567890"},
+		{name: "email with sms sender", recipient: "jane@example.com", withSms: true},
+		{name: "sms with sms sender", recipient: "+6281234567890", withSms: true, wantSms: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fallback := NewMemorySender()
+			sms := NewMemorySender()
+
+			router := NewRouter(fallback)
+			if tt.withSms {
+				router.WithChannel(ChannelSms, sms)
+			}
+
+			err := router.Send(context.Background(), &Message{
+				Channel:   ChannelOf(tt.recipient),
+				Recipient: tt.recipient,
+				Body:      "code",
+			})
+			if err != nil {
+				t.Fatalf("Send() error = %v", err)
+			}
+
+			want, other := fallback, sms
+			if tt.wantSms {
+				want, other = sms, fallback
+			}
+			if want.Last(tt.recipient) == nil {
+				t.Errorf("message was not delivered by the expected sender")
+			}
+			if len(other.Messages()) != 0 {
+				t.Errorf("message was delivered by another sender")
+			}
+		})
+	}
+}
+
+func TestRouterGetSenderName(t *testing.T) {
+	router := NewRouter(NewMemorySender()).
+		WithChannel(ChannelSms, NewMemorySender()).
+		WithChannel(ChannelEmail, NewMemorySender())
+
+	if got, want := router.GetSenderName(), "memory,email=memory,sms=memory"; got != want {
+		t.Errorf("GetSenderName() = %q, want %q", got, want)
+	}
+}
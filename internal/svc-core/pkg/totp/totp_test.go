@@ -0,0 +1,92 @@
+package totp
+
+import (
+	"net/url"
+	"testing"
+	"time"
+)
+
+// Base32 of the RFC 6238 SHA1 test secret "12345678901234567890"
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		secret   string
+		code     string
+		time     int64
+		skew     int
+		wantStep int64
+		wantOk   bool
+	}{
+		// RFC 6238 appendix B vectors, truncated to 6 digits
+		{name: "rfc 59", secret: rfcSecret, code: "287082", time: 59, wantStep: 1, wantOk: true},
+		{name: "rfc 1111111109", secret: rfcSecret, code: "081804", time: 1111111109, wantStep: 37037036, wantOk: true},
+		{name: "rfc 1111111111", secret: rfcSecret, code: "050471", time: 1111111111, wantStep: 37037037, wantOk: true},
+		{name: "rfc 1234567890", secret: rfcSecret, code: "005924", time: 1234567890, wantStep: 41152263, wantOk: true},
+		{name: "rfc 2000000000", secret: rfcSecret, code: "279037", time: 2000000000, wantStep: 66666666, wantOk: true},
+		{name: "lowercase secret with spaces", secret: " gezdgnbvgy3tqojqgezdgnbvgy3tqojq ", code: "287082", time: 59, wantStep: 1, wantOk: true},
+		{name: "previous step within skew", secret: rfcSecret, code: "287082", time: 89, skew: 1, wantStep: 1, wantOk: true},
+		{name: "next step within skew", secret: rfcSecret, code: "287082", time: 29, skew: 1, wantStep: 1, wantOk: true},
+		{name: "previous step without skew", secret: rfcSecret, code: "287082", time: 89},
+		{name: "outside skew", secret: rfcSecret, code: "287082", time: 119, skew: 1},
+		{name: "wrong code", secret: rfcSecret, code: "287083", time: 59},
+		{name: "short code", secret: rfcSecret, code: "28708", time: 59},
+		{name: "long code", secret: rfcSecret, code: "2870820", time: 59},
+		{name: "invalid secret", secret: "not base32!", code: "287082", time: 59},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := Validate(tt.secret, tt.code, time.Unix(tt.time, 0), tt.skew)
+			if ok != tt.wantOk || step != tt.wantStep {
+				t.Errorf("Validate() = (%d, %t), want (%d, %t)", step, ok, tt.wantStep, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestGenerateSecret(t *testing.T) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		t.Fatalf("GenerateSecret() error = %v", err)
+	}
+
+	key, err := encoding.DecodeString(secret)
+	if err != nil {
+		t.Fatalf("GenerateSecret() = %q is not base32: %v", secret, err)
+	}
+	if len(key) != secretSize {
+		t.Errorf("GenerateSecret() key length = %d, want %d", len(key), secretSize)
+	}
+
+	code := generate(key, time.Now().Unix()/Period)
+	if _, ok := Validate(secret, code, time.Now(), 0); !ok {
+		t.Errorf("Validate() rejected current code %q of generated secret", code)
+	}
+}
+
+func TestURI(t *testing.T) {
+	u, err := url.Parse(URI("Acme", "jane@example.com", rfcSecret))
+	if err != nil {
+		t.Fatalf("URI() is not a valid URL: %v", err)
+	}
+
+	if u.Scheme != "otpauth" || u.Host != "totp" || u.Path != "/Acme:jane@example.com" {
+		t.Errorf("URI() = %s, want otpauth://totp/Acme:jane@example.com", u)
+	}
+
+	want := map[string]string{
+		"secret":    rfcSecret,
+		"issuer":    "Acme",
+		"algorithm": "SHA1",
+		"digits":    "6",
+		"period":    "30",
+	}
+	q := u.Query()
+	for key, val := range want {
+		if got := q.Get(key); got != val {
+			t.Errorf("URI() %s = %q, want %q", key, got, val)
+		}
+	}
+}
@@ -30,12 +30,124 @@ func (r *Repository) FindSessionByXid(xid string) (*model.AuthSession, error) {
 	return &m, nil
 }
 
+// FindSessionXidsBySubjectId returns xid of every session indexed for the subject, including expired ones
+func (r *Repository) FindSessionXidsBySubjectId(subjectId string) ([]string, error) {
+	key := fmt.Sprintf("%s%s", constant.RedisSubjectSessionPrefix, subjectId)
+
+	xids, err := r.redis.SMembers(key)
+	if err != nil {
+		return nil, errk.Trace(err)
+	}
+
+	return xids, nil
+}
+
+// FindSessionsBySubjectId returns every live session of the subject, pruning expired xids from its session index
+func (r *Repository) FindSessionsBySubjectId(subjectId string) ([]*model.AuthSession, error) {
+	xids, err := r.FindSessionXidsBySubjectId(subjectId)
+	if err != nil {
+		return nil, errk.Trace(err)
+	}
+	if len(xids) == 0 {
+		return nil, nil
+	}
+
+	keys := make([]string, len(xids))
+	for i, xid := range xids {
+		keys[i] = fmt.Sprintf("%s%s", constant.RedisSessionPrefix, xid)
+	}
+
+	values, err := r.redis.MGet(keys...)
+	if err != nil {
+		return nil, errk.Trace(err)
+	}
+
+	var sessions []*model.AuthSession
+	var staleXids []interface{}
+	for i, val := range values {
+		str, ok := val.(string)
+		if !ok {
+			staleXids = append(staleXids, xids[i])
+			continue
+		}
+
+		var m model.AuthSession
+		if err = json.Unmarshal([]byte(str), &m); err != nil {
+			return nil, errk.Trace(err)
+		}
+		sessions = append(sessions, &m)
+	}
+
+	if len(staleXids) > 0 {
+		indexKey := fmt.Sprintf("%s%s", constant.RedisSubjectSessionPrefix, subjectId)
+		_, err = r.redis.SRem(indexKey, staleXids...)
+		if err != nil {
+			return nil, errk.Trace(err)
+		}
+	}
+
+	return sessions, nil
+}
+
 func (r *Repository) DeleteSessionByXid(xid string) error {
+	// Find session to remove it from subject index
+	session, err := r.FindSessionByXid(xid)
+	if err != nil {
+		return errk.Trace(err)
+	}
+
 	key := fmt.Sprintf("%s%s", constant.RedisSessionPrefix, xid)
-	_, err := r.redis.Del(key)
+	_, err = r.redis.Del(key)
 	if err != nil {
 		return errk.Trace(err)
 	}
+
+	if session != nil {
+		indexKey := fmt.Sprintf("%s%s", constant.RedisSubjectSessionPrefix, session.SubjectId)
+		_, err = r.redis.SRem(indexKey, xid)
+		if err != nil {
+			return errk.Trace(err)
+		}
+	}
+
+	return nil
+}
+
+// UpdateAuthSession overwrites a stored session, keeping its expiration. Deleted session is not recreated
+func (r *Repository) UpdateAuthSession(session *model.AuthSession) error {
+	key := fmt.Sprintf("%s%s", constant.RedisSessionPrefix, session.Xid)
+
+	data, err := json.Marshal(session)
+	if err != nil {
+		return errk.Trace(err)
+	}
+
+	_, err = r.redis.Replace(key, data)
+	if err != nil {
+		return errk.Trace(err)
+	}
+
+	return nil
+}
+
+// DeleteSessionsBySubjectId deletes every session of the subject and its session index
+func (r *Repository) DeleteSessionsBySubjectId(subjectId string) error {
+	xids, err := r.FindSessionXidsBySubjectId(subjectId)
+	if err != nil {
+		return errk.Trace(err)
+	}
+
+	indexKey := fmt.Sprintf("%s%s", constant.RedisSubjectSessionPrefix, subjectId)
+	keys := []string{indexKey}
+	for _, xid := range xids {
+		keys = append(keys, fmt.Sprintf("%s%s", constant.RedisSessionPrefix, xid))
+	}
+
+	_, err = r.redis.Del(keys...)
+	if err != nil {
+		return errk.Trace(err)
+	}
+
 	return nil
 }
 
@@ -52,10 +164,41 @@ func (r *Repository) InsertAuthSession(session *model.AuthSession) error {
 		lifetime = 3600 * time.Second
 	}
 
+	// Keep session as long as its refresh token can be exchanged
+	if r.config.UserSessionRefreshLifetime > lifetime {
+		lifetime = r.config.UserSessionRefreshLifetime
+	}
+
 	err = r.redis.Set(key, data, lifetime)
 	if err != nil {
 		return errk.Trace(err)
 	}
 
+	// Index session by subject, index lives as long as the latest session
+	indexKey := fmt.Sprintf("%s%s", constant.RedisSubjectSessionPrefix, session.SubjectId)
+	_, err = r.redis.SAdd(indexKey, session.Xid)
+	if err != nil {
+		return errk.Trace(err)
+	}
+
+	_, err = r.redis.Expire(indexKey, lifetime)
+	if err != nil {
+		return errk.Trace(err)
+	}
+
+	// Index session by refresh token family
+	if session.FamilyId != "" {
+		familyKey := fmt.Sprintf("%s%s", constant.RedisTokenFamilyPrefix, session.FamilyId)
+		_, err = r.redis.SAdd(familyKey, session.Xid)
+		if err != nil {
+			return errk.Trace(err)
+		}
+
+		_, err = r.redis.Expire(familyKey, lifetime)
+		if err != nil {
+			return errk.Trace(err)
+		}
+	}
+
 	return nil
 }
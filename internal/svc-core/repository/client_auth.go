@@ -1,6 +1,10 @@
 package repository
 
 import (
+	"fmt"
+	"time"
+
+	"github.com/konsultin/project-goes-here/internal/svc-core/constant"
 	"github.com/konsultin/project-goes-here/internal/svc-core/model"
 	"github.com/go-konsultin/errk"
 )
@@ -13,3 +17,93 @@ func (r *Repository) FindClientAuthByClientId(id string) (*model.ClientAuth, err
 	}
 	return &m, nil
 }
+
+// FindClientAuths returns every client
+func (r *Repository) FindClientAuths() ([]*model.ClientAuth, error) {
+	var m []*model.ClientAuth
+	err := r.sql.ClientAuth.FindAll.SelectContext(r.ctx, &m)
+	if err != nil {
+		return nil, errk.Trace(err)
+	}
+	return m, nil
+}
+
+// InsertClientAuth creates a client, setting its id
+func (r *Repository) InsertClientAuth(m *model.ClientAuth) error {
+	err := r.sql.ClientAuth.Insert.GetContext(r.ctx, &m.Id, m.Name, m.ClientId, m.ClientTypeId, m.Options, m.StatusId,
+		m.CreatedAt, m.UpdatedAt, m.ModifiedBy, m.Version, m.Metadata)
+	if err != nil {
+		return errk.Trace(err)
+	}
+	return nil
+}
+
+// UpdateClientAuth updates name, options and status of a client, recording the subject modifying it
+func (r *Repository) UpdateClientAuth(m *model.ClientAuth) error {
+	_, err := r.sql.ClientAuth.Update.ExecContext(r.ctx, m.Name, m.Options, m.StatusId, m.ModifiedBy, m.Id)
+	if err != nil {
+		return errk.Trace(err)
+	}
+	return nil
+}
+
+// UpdateClientAuthOptions replaces the options of a client if it is still at the version they were read from.
+// Returns false if the client has been modified since
+func (r *Repository) UpdateClientAuthOptions(id int64, options *model.ClientAuthOptions, version int64) (bool, error) {
+	result, err := r.sql.ClientAuth.UpdateOptions.ExecContext(r.ctx, options, id, version)
+	if err != nil {
+		return false, errk.Trace(err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, errk.Trace(err)
+	}
+
+	return affected == 1, nil
+}
+
+// InsertInactiveClient marks tokens issued to a client as rejected, until every token issued before has expired
+func (r *Repository) InsertInactiveClient(clientId string, lifetime time.Duration) error {
+	err := r.redis.Set(fmt.Sprintf("%s%s", constant.RedisInactiveClientPrefix, clientId), 1, lifetime)
+	if err != nil {
+		return errk.Trace(err)
+	}
+	return nil
+}
+
+// ExistsInactiveClient checks if tokens issued to a client are rejected
+func (r *Repository) ExistsInactiveClient(clientId string) (bool, error) {
+	exists, err := r.redis.Exists(fmt.Sprintf("%s%s", constant.RedisInactiveClientPrefix, clientId))
+	if err != nil {
+		return false, errk.Trace(err)
+	}
+	return exists, nil
+}
+
+// DeleteInactiveClient accepts tokens issued to a client again
+func (r *Repository) DeleteInactiveClient(clientId string) error {
+	_, err := r.redis.Del(fmt.Sprintf("%s%s", constant.RedisInactiveClientPrefix, clientId))
+	if err != nil {
+		return errk.Trace(err)
+	}
+	return nil
+}
+
+// InsertRevokedToken rejects a token not backed by a session, until it expires
+func (r *Repository) InsertRevokedToken(subjectId string, jti string, lifetime time.Duration) error {
+	err := r.redis.Set(fmt.Sprintf("%s%s:%s", constant.RedisRevokedTokenPrefix, subjectId, jti), 1, lifetime)
+	if err != nil {
+		return errk.Trace(err)
+	}
+	return nil
+}
+
+// ExistsRevokedToken checks if a token not backed by a session has been revoked
+func (r *Repository) ExistsRevokedToken(subjectId string, jti string) (bool, error) {
+	exists, err := r.redis.Exists(fmt.Sprintf("%s%s:%s", constant.RedisRevokedTokenPrefix, subjectId, jti))
+	if err != nil {
+		return false, errk.Trace(err)
+	}
+	return exists, nil
+}
@@ -124,6 +124,13 @@ func (r *Repository) Connect(ctx context.Context) (*Repository, error) {
 	return &newR, nil
 }
 
+// WithRedis returns a shallow copy of the repository using the redis client
+func (r *Repository) WithRedis(client *redis.Client) *Repository {
+	newR := *r
+	newR.redis = client
+	return &newR
+}
+
 func (r *Repository) Ping(ctx context.Context) error {
 	if r == nil || r.db == nil {
 		return fmt.Errorf("repository not initialized")
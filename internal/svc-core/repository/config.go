@@ -7,8 +7,9 @@ import (
 )
 
 type RepositoryConfig struct {
-	Timeout             time.Duration
-	UserSessionLifetime time.Duration
+	Timeout                    time.Duration
+	UserSessionLifetime        time.Duration
+	UserSessionRefreshLifetime time.Duration
 }
 
 func NewRepositoryConfig(config *config.Config) (*RepositoryConfig, error) {
@@ -16,6 +17,7 @@ func NewRepositoryConfig(config *config.Config) (*RepositoryConfig, error) {
 
 	repoConfig.Timeout = time.Duration(config.DatabaseTimeoutSeconds) * time.Second
 	repoConfig.UserSessionLifetime = time.Duration(config.UserSessionLifetime) * time.Second
+	repoConfig.UserSessionRefreshLifetime = time.Duration(config.UserSessionRefreshLifetime) * time.Second
 
 	return repoConfig, nil
 }
@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/go-konsultin/errk"
+	"github.com/konsultin/project-goes-here/internal/svc-core/constant"
+	"github.com/konsultin/project-goes-here/internal/svc-core/model"
+	"github.com/konsultin/project-goes-here/pkg/redis"
+)
+
+// InsertCredentialVerification stores a pending verification, replacing the previous code of the credential
+func (r *Repository) InsertCredentialVerification(verification *model.CredentialVerification, lifetime time.Duration) error {
+	key := fmt.Sprintf("%s%d", constant.RedisCredentialVerifyPrefix, verification.CredentialId)
+
+	data, err := json.Marshal(verification)
+	if err != nil {
+		return errk.Trace(err)
+	}
+
+	err = r.redis.Set(key, data, lifetime)
+	if err != nil {
+		return errk.Trace(err)
+	}
+
+	return nil
+}
+
+// FindCredentialVerification returns pending verification of a credential, nil if it does not exist
+func (r *Repository) FindCredentialVerification(credentialId int64) (*model.CredentialVerification, error) {
+	key := fmt.Sprintf("%s%d", constant.RedisCredentialVerifyPrefix, credentialId)
+
+	val, err := r.redis.Get(key)
+	if redis.IsNil(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errk.Trace(err)
+	}
+
+	var m model.CredentialVerification
+	if err = json.Unmarshal([]byte(val), &m); err != nil {
+		return nil, errk.Trace(err)
+	}
+
+	return &m, nil
+}
+
+// DeleteCredentialVerification removes pending verification of a credential
+func (r *Repository) DeleteCredentialVerification(credentialId int64) error {
+	key := fmt.Sprintf("%s%d", constant.RedisCredentialVerifyPrefix, credentialId)
+
+	_, err := r.redis.Del(key)
+	if err != nil {
+		return errk.Trace(err)
+	}
+
+	return nil
+}
+
+// InsertVerificationCooldown blocks verification code requests of an identifier for the interval. Returns false if it is already blocked
+func (r *Repository) InsertVerificationCooldown(identifier string, interval time.Duration) (bool, error) {
+	ok, err := r.redis.SetNX(fmt.Sprintf("%s%s", constant.RedisVerifyCooldownPrefix, identifier), 1, interval)
+	if err != nil {
+		return false, errk.Trace(err)
+	}
+	return ok, nil
+}
+
+// IncrVerificationRequest counts a verification code request of an identifier within the window. Returns the number of requests
+func (r *Repository) IncrVerificationRequest(identifier string, window time.Duration) (int64, error) {
+	return r.incrWithinWindow(fmt.Sprintf("%s%s", constant.RedisVerifyRequestPrefix, identifier), window)
+}
+
+// IncrVerificationAttempt counts an attempt to confirm the pending verification of a credential. Returns the number of attempts
+func (r *Repository) IncrVerificationAttempt(credentialId int64, lifetime time.Duration) (int64, error) {
+	return r.incrWithinWindow(fmt.Sprintf("%s%d", constant.RedisVerifyAttemptPrefix, credentialId), lifetime)
+}
+
+// ExpireVerificationAttempt keeps attempts of a credential as long as its latest code, if there are any
+func (r *Repository) ExpireVerificationAttempt(credentialId int64, lifetime time.Duration) error {
+	_, err := r.redis.Expire(fmt.Sprintf("%s%d", constant.RedisVerifyAttemptPrefix, credentialId), lifetime)
+	if err != nil {
+		return errk.Trace(err)
+	}
+	return nil
+}
+
+// DeleteVerificationAttempt resets attempts to confirm the pending verification of a credential
+func (r *Repository) DeleteVerificationAttempt(credentialId int64) error {
+	_, err := r.redis.Del(fmt.Sprintf("%s%d", constant.RedisVerifyAttemptPrefix, credentialId))
+	if err != nil {
+		return errk.Trace(err)
+	}
+	return nil
+}
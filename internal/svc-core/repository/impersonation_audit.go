@@ -0,0 +1,15 @@
+package repository
+
+import (
+	"github.com/go-konsultin/errk"
+	"github.com/konsultin/project-goes-here/internal/svc-core/model"
+)
+
+// InsertImpersonationAudit appends an event to the impersonation audit trail, setting its id
+func (r *Repository) InsertImpersonationAudit(m *model.ImpersonationAudit) error {
+	err := r.sql.ImpersonationAudit.Insert.GetContext(r.ctx, &m.Id, m)
+	if err != nil {
+		return errk.Trace(err)
+	}
+	return nil
+}
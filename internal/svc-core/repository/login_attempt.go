@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-konsultin/errk"
+	"github.com/konsultin/project-goes-here/internal/svc-core/constant"
+	"github.com/konsultin/project-goes-here/internal/svc-core/model"
+	"github.com/konsultin/project-goes-here/pkg/redis"
+)
+
+// IncrLoginFailure counts a failed login of an identifier within the window. Returns the number of failures
+func (r *Repository) IncrLoginFailure(identifier string, window time.Duration) (int64, error) {
+	return r.incrWithinWindow(fmt.Sprintf("%s%s", constant.RedisLoginFailurePrefix, identifier), window)
+}
+
+// IncrUserLoginFailure counts a failed login of a user within the window, whichever identifier is used.
+// Returns the number of failures
+func (r *Repository) IncrUserLoginFailure(userId int64, window time.Duration) (int64, error) {
+	return r.incrWithinWindow(fmt.Sprintf("%s%d", constant.RedisUserLoginFailurePrefix, userId), window)
+}
+
+// DeleteUserLoginFailure resets failed login counter of a user
+func (r *Repository) DeleteUserLoginFailure(userId int64) error {
+	_, err := r.redis.Del(fmt.Sprintf("%s%d", constant.RedisUserLoginFailurePrefix, userId))
+	if err != nil {
+		return errk.Trace(err)
+	}
+	return nil
+}
+
+// IncrLoginFailureByIp counts a failed login from an IP within the window. Returns the number of failures
+func (r *Repository) IncrLoginFailureByIp(ip string, window time.Duration) (int64, error) {
+	return r.incrWithinWindow(fmt.Sprintf("%s%s", constant.RedisLoginFailureIpPrefix, ip), window)
+}
+
+// FindLoginFailureByIp returns the number of failed logins from an IP within the current window
+func (r *Repository) FindLoginFailureByIp(ip string) (int64, error) {
+	val, err := r.redis.Get(fmt.Sprintf("%s%s", constant.RedisLoginFailureIpPrefix, ip))
+	if redis.IsNil(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, errk.Trace(err)
+	}
+
+	count, err := strconv.ParseInt(val, 10, 64)
+	if err != nil {
+		return 0, errk.Trace(err)
+	}
+
+	return count, nil
+}
+
+// DeleteLoginFailure resets failed login counter and delay of an identifier
+func (r *Repository) DeleteLoginFailure(identifier string) error {
+	_, err := r.redis.Del(
+		fmt.Sprintf("%s%s", constant.RedisLoginFailurePrefix, identifier),
+		fmt.Sprintf("%s%s", constant.RedisLoginDelayPrefix, identifier),
+	)
+	if err != nil {
+		return errk.Trace(err)
+	}
+	return nil
+}
+
+// InsertLoginDelay rejects login attempts of an identifier until the delay has passed
+func (r *Repository) InsertLoginDelay(identifier string, delay time.Duration) error {
+	err := r.redis.SetEX(fmt.Sprintf("%s%s", constant.RedisLoginDelayPrefix, identifier), 1, delay)
+	if err != nil {
+		return errk.Trace(err)
+	}
+	return nil
+}
+
+// ExistsLoginDelay checks if login attempts of an identifier are delayed
+func (r *Repository) ExistsLoginDelay(identifier string) (bool, error) {
+	exists, err := r.redis.Exists(fmt.Sprintf("%s%s", constant.RedisLoginDelayPrefix, identifier))
+	if err != nil {
+		return false, errk.Trace(err)
+	}
+	return exists, nil
+}
+
+// InsertUserLockout stores lockout of a user. It does not expire, so an automatic lockout can be told apart
+// from a user locked by other means once the unlock time has passed
+func (r *Repository) InsertUserLockout(lockout *model.UserLockout) error {
+	data, err := json.Marshal(lockout)
+	if err != nil {
+		return errk.Trace(err)
+	}
+
+	err = r.redis.Set(fmt.Sprintf("%s%d", constant.RedisUserLockoutPrefix, lockout.UserId), data, 0)
+	if err != nil {
+		return errk.Trace(err)
+	}
+
+	return nil
+}
+
+// FindUserLockout returns lockout of a user, nil if it does not exist
+func (r *Repository) FindUserLockout(userId int64) (*model.UserLockout, error) {
+	val, err := r.redis.Get(fmt.Sprintf("%s%d", constant.RedisUserLockoutPrefix, userId))
+	if redis.IsNil(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errk.Trace(err)
+	}
+
+	var m model.UserLockout
+	if err = json.Unmarshal([]byte(val), &m); err != nil {
+		return nil, errk.Trace(err)
+	}
+
+	return &m, nil
+}
+
+// DeleteUserLockout removes lockout of a user
+func (r *Repository) DeleteUserLockout(userId int64) error {
+	_, err := r.redis.Del(fmt.Sprintf("%s%d", constant.RedisUserLockoutPrefix, userId))
+	if err != nil {
+		return errk.Trace(err)
+	}
+	return nil
+}
+
+// incrWithinWindow increments a counter that expires after the window starting at its first increment
+func (r *Repository) incrWithinWindow(key string, window time.Duration) (int64, error) {
+	count, err := r.redis.Incr(key)
+	if err != nil {
+		return 0, errk.Trace(err)
+	}
+
+	if count == 1 {
+		_, err = r.redis.Expire(key, window)
+		if err != nil {
+			return 0, errk.Trace(err)
+		}
+	}
+
+	return count, nil
+}
@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/go-konsultin/errk"
+	"github.com/konsultin/project-goes-here/internal/svc-core/constant"
+	"github.com/konsultin/project-goes-here/internal/svc-core/model"
+	"github.com/konsultin/project-goes-here/pkg/redis"
+)
+
+// InsertLoginOtp stores a one-time login code of an identifier, replacing the previous code
+func (r *Repository) InsertLoginOtp(otp *model.LoginOtp, lifetime time.Duration) error {
+	data, err := json.Marshal(otp)
+	if err != nil {
+		return errk.Trace(err)
+	}
+
+	err = r.redis.Set(fmt.Sprintf("%s%s", constant.RedisLoginOtpPrefix, otp.Identifier), data, lifetime)
+	if err != nil {
+		return errk.Trace(err)
+	}
+
+	return nil
+}
+
+// FindLoginOtp returns the one-time login code of an identifier, nil if it does not exist
+func (r *Repository) FindLoginOtp(identifier string) (*model.LoginOtp, error) {
+	val, err := r.redis.Get(fmt.Sprintf("%s%s", constant.RedisLoginOtpPrefix, identifier))
+	if redis.IsNil(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errk.Trace(err)
+	}
+
+	var m model.LoginOtp
+	if err = json.Unmarshal([]byte(val), &m); err != nil {
+		return nil, errk.Trace(err)
+	}
+
+	return &m, nil
+}
+
+// ConsumeLoginOtp deletes the one-time login code of an identifier. Returns false if it was already consumed or has expired
+func (r *Repository) ConsumeLoginOtp(identifier string) (bool, error) {
+	_, err := r.redis.GetDel(fmt.Sprintf("%s%s", constant.RedisLoginOtpPrefix, identifier))
+	if redis.IsNil(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, errk.Trace(err)
+	}
+	return true, nil
+}
+
+// InsertLoginOtpCooldown blocks code requests of an identifier for the interval. Returns false if it is already blocked
+func (r *Repository) InsertLoginOtpCooldown(identifier string, interval time.Duration) (bool, error) {
+	ok, err := r.redis.SetNX(fmt.Sprintf("%s%s", constant.RedisLoginOtpCooldownPrefix, identifier), 1, interval)
+	if err != nil {
+		return false, errk.Trace(err)
+	}
+	return ok, nil
+}
+
+// IncrLoginOtpRequest counts a code request of an identifier within the window. Returns the number of requests
+func (r *Repository) IncrLoginOtpRequest(identifier string, window time.Duration) (int64, error) {
+	return r.incrWithinWindow(fmt.Sprintf("%s%s", constant.RedisLoginOtpRequestPrefix, identifier), window)
+}
+
+// IncrLoginOtpAttempt counts an attempt to login with the code of an identifier. Returns the number of attempts
+func (r *Repository) IncrLoginOtpAttempt(identifier string, lifetime time.Duration) (int64, error) {
+	return r.incrWithinWindow(fmt.Sprintf("%s%s", constant.RedisLoginOtpAttemptPrefix, identifier), lifetime)
+}
+
+// ExpireLoginOtpAttempt keeps attempts of an identifier as long as its latest code, if there are any
+func (r *Repository) ExpireLoginOtpAttempt(identifier string, lifetime time.Duration) error {
+	_, err := r.redis.Expire(fmt.Sprintf("%s%s", constant.RedisLoginOtpAttemptPrefix, identifier), lifetime)
+	if err != nil {
+		return errk.Trace(err)
+	}
+	return nil
+}
+
+// DeleteLoginOtpAttempt resets attempts to login with the code of an identifier
+func (r *Repository) DeleteLoginOtpAttempt(identifier string) error {
+	_, err := r.redis.Del(fmt.Sprintf("%s%s", constant.RedisLoginOtpAttemptPrefix, identifier))
+	if err != nil {
+		return errk.Trace(err)
+	}
+	return nil
+}
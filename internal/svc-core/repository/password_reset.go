@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/go-konsultin/errk"
+	"github.com/konsultin/project-goes-here/internal/svc-core/constant"
+	"github.com/konsultin/project-goes-here/internal/svc-core/model"
+	"github.com/konsultin/project-goes-here/pkg/redis"
+)
+
+// InsertPasswordResetToken stores a password reset token by its hash, replacing pending token of the user
+func (r *Repository) InsertPasswordResetToken(tokenHash string, token *model.PasswordResetToken, lifetime time.Duration) error {
+	userKey := fmt.Sprintf("%s%d", constant.RedisUserPasswordResetPrefix, token.UserId)
+
+	// Invalidate previous token, only the latest link works
+	prevHash, err := r.redis.GetDel(userKey)
+	if err != nil && !redis.IsNil(err) {
+		return errk.Trace(err)
+	}
+	if prevHash != "" {
+		_, err = r.redis.Del(fmt.Sprintf("%s%s", constant.RedisPasswordResetTokenPrefix, prevHash))
+		if err != nil {
+			return errk.Trace(err)
+		}
+	}
+
+	data, err := json.Marshal(token)
+	if err != nil {
+		return errk.Trace(err)
+	}
+
+	key := fmt.Sprintf("%s%s", constant.RedisPasswordResetTokenPrefix, tokenHash)
+	err = r.redis.Set(key, data, lifetime)
+	if err != nil {
+		return errk.Trace(err)
+	}
+
+	err = r.redis.Set(userKey, tokenHash, lifetime)
+	if err != nil {
+		return errk.Trace(err)
+	}
+
+	return nil
+}
+
+// FindPasswordResetToken returns a password reset token by its hash without consuming it, nil if it does not exist
+func (r *Repository) FindPasswordResetToken(tokenHash string) (*model.PasswordResetToken, error) {
+	key := fmt.Sprintf("%s%s", constant.RedisPasswordResetTokenPrefix, tokenHash)
+
+	val, err := r.redis.Get(key)
+	if redis.IsNil(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errk.Trace(err)
+	}
+
+	var m model.PasswordResetToken
+	if err = json.Unmarshal([]byte(val), &m); err != nil {
+		return nil, errk.Trace(err)
+	}
+
+	return &m, nil
+}
+
+// ConsumePasswordResetToken returns and deletes a password reset token by its hash, nil if it does not exist
+func (r *Repository) ConsumePasswordResetToken(tokenHash string) (*model.PasswordResetToken, error) {
+	key := fmt.Sprintf("%s%s", constant.RedisPasswordResetTokenPrefix, tokenHash)
+
+	val, err := r.redis.GetDel(key)
+	if redis.IsNil(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errk.Trace(err)
+	}
+
+	var m model.PasswordResetToken
+	if err = json.Unmarshal([]byte(val), &m); err != nil {
+		return nil, errk.Trace(err)
+	}
+
+	_, err = r.redis.Del(fmt.Sprintf("%s%d", constant.RedisUserPasswordResetPrefix, m.UserId))
+	if err != nil {
+		return nil, errk.Trace(err)
+	}
+
+	return &m, nil
+}
@@ -42,3 +42,12 @@ func (r *Repository) FindRolePrivilegeByRoleId(roleId int32) ([]model.RolePrivil
 
 	return rows, nil
 }
+
+func (r *Repository) FindAllPrivileges() ([]model.Privilege, error) {
+	var rows []model.Privilege
+	err := r.sql.Privilege.FindAll.SelectContext(r.ctx, &rows)
+	if err != nil {
+		return nil, errk.Trace(err)
+	}
+	return rows, nil
+}
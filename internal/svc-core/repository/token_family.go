@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/go-konsultin/errk"
+	"github.com/konsultin/project-goes-here/internal/svc-core/constant"
+	"github.com/konsultin/project-goes-here/internal/svc-core/model"
+	"github.com/konsultin/project-goes-here/pkg/redis"
+)
+
+// InsertRefreshTokenRotation marks the refresh token of a session as rotated.
+// Returns false if the refresh token has already been rotated
+func (r *Repository) InsertRefreshTokenRotation(rotation *model.RefreshTokenRotation) (bool, error) {
+	key := fmt.Sprintf("%s%s", constant.RedisRotatedRefreshTokenPrefix, rotation.SessionXid)
+
+	data, err := json.Marshal(rotation)
+	if err != nil {
+		return false, errk.Trace(err)
+	}
+
+	ok, err := r.redis.SetNX(key, data, r.config.UserSessionRefreshLifetime)
+	if err != nil {
+		return false, errk.Trace(err)
+	}
+
+	return ok, nil
+}
+
+// FindRefreshTokenRotation returns rotation record of a session refresh token, nil if it has not been rotated
+func (r *Repository) FindRefreshTokenRotation(sessionXid string) (*model.RefreshTokenRotation, error) {
+	key := fmt.Sprintf("%s%s", constant.RedisRotatedRefreshTokenPrefix, sessionXid)
+
+	val, err := r.redis.Get(key)
+	if redis.IsNil(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errk.Trace(err)
+	}
+
+	var m model.RefreshTokenRotation
+	if err := json.Unmarshal([]byte(val), &m); err != nil {
+		return nil, errk.Trace(err)
+	}
+
+	return &m, nil
+}
+
+// ExistsTokenFamily checks if a refresh token family is still alive, i.e. has not been revoked or expired
+func (r *Repository) ExistsTokenFamily(familyId string) (bool, error) {
+	key := fmt.Sprintf("%s%s", constant.RedisTokenFamilyPrefix, familyId)
+
+	ok, err := r.redis.Exists(key)
+	if err != nil {
+		return false, errk.Trace(err)
+	}
+
+	return ok, nil
+}
+
+// DeleteSessionsByFamilyId deletes every session issued in a refresh token family and the family itself
+func (r *Repository) DeleteSessionsByFamilyId(subjectId, familyId string) error {
+	familyKey := fmt.Sprintf("%s%s", constant.RedisTokenFamilyPrefix, familyId)
+
+	xids, err := r.redis.SMembers(familyKey)
+	if err != nil {
+		return errk.Trace(err)
+	}
+
+	keys := []string{familyKey}
+	members := make([]interface{}, 0, len(xids))
+	for _, xid := range xids {
+		keys = append(keys, fmt.Sprintf("%s%s", constant.RedisSessionPrefix, xid))
+		members = append(members, xid)
+	}
+
+	_, err = r.redis.Del(keys...)
+	if err != nil {
+		return errk.Trace(err)
+	}
+
+	if len(members) > 0 {
+		indexKey := fmt.Sprintf("%s%s", constant.RedisSubjectSessionPrefix, subjectId)
+		_, err = r.redis.SRem(indexKey, members...)
+		if err != nil {
+			return errk.Trace(err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-konsultin/errk"
+	"github.com/konsultin/project-goes-here/internal/svc-core/constant"
+	"github.com/konsultin/project-goes-here/internal/svc-core/model"
+	"github.com/konsultin/project-goes-here/pkg/redis"
+)
+
+// InsertTwoFactorChallenge stores a two-factor challenge by hash of its token
+func (r *Repository) InsertTwoFactorChallenge(tokenHash string, challenge *model.TwoFactorChallenge, lifetime time.Duration) error {
+	data, err := json.Marshal(challenge)
+	if err != nil {
+		return errk.Trace(err)
+	}
+
+	err = r.redis.Set(fmt.Sprintf("%s%s", constant.RedisTwoFactorChallengePrefix, tokenHash), data, lifetime)
+	if err != nil {
+		return errk.Trace(err)
+	}
+
+	return nil
+}
+
+// FindTwoFactorChallenge returns a two-factor challenge by hash of its token, nil if it does not exist
+func (r *Repository) FindTwoFactorChallenge(tokenHash string) (*model.TwoFactorChallenge, error) {
+	val, err := r.redis.Get(fmt.Sprintf("%s%s", constant.RedisTwoFactorChallengePrefix, tokenHash))
+	if redis.IsNil(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errk.Trace(err)
+	}
+
+	var m model.TwoFactorChallenge
+	if err = json.Unmarshal([]byte(val), &m); err != nil {
+		return nil, errk.Trace(err)
+	}
+
+	return &m, nil
+}
+
+// ConsumeTwoFactorChallenge deletes a two-factor challenge. Returns false if it was already consumed or has expired
+func (r *Repository) ConsumeTwoFactorChallenge(tokenHash string) (bool, error) {
+	_, err := r.redis.GetDel(fmt.Sprintf("%s%s", constant.RedisTwoFactorChallengePrefix, tokenHash))
+	if redis.IsNil(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, errk.Trace(err)
+	}
+	return true, nil
+}
+
+// IncrTwoFactorAttempt counts an attempt to complete a two-factor challenge by hash of its token. Returns the number of attempts
+func (r *Repository) IncrTwoFactorAttempt(tokenHash string, lifetime time.Duration) (int64, error) {
+	return r.incrWithinWindow(fmt.Sprintf("%s%s", constant.RedisTwoFactorAttemptPrefix, tokenHash), lifetime)
+}
+
+// DeleteTwoFactorAttempt resets attempts to complete a two-factor challenge by hash of its token
+func (r *Repository) DeleteTwoFactorAttempt(tokenHash string) error {
+	_, err := r.redis.Del(fmt.Sprintf("%s%s", constant.RedisTwoFactorAttemptPrefix, tokenHash))
+	if err != nil {
+		return errk.Trace(err)
+	}
+	return nil
+}
+
+// InsertTotpUsedStep records a TOTP time step used by a credential. Returns false if the step was already used
+func (r *Repository) InsertTotpUsedStep(credentialId int64, step int64, lifetime time.Duration) (bool, error) {
+	ok, err := r.redis.SetNX(fmt.Sprintf("%s%d:%d", constant.RedisTotpUsedStepPrefix, credentialId, step), 1, lifetime)
+	if err != nil {
+		return false, errk.Trace(err)
+	}
+	return ok, nil
+}
+
+// IncrTwoFactorFailure counts an invalid two-factor code of a signed in user within the window. Returns the number of failures
+func (r *Repository) IncrTwoFactorFailure(userId int64, window time.Duration) (int64, error) {
+	return r.incrWithinWindow(fmt.Sprintf("%s%d", constant.RedisTwoFactorFailurePrefix, userId), window)
+}
+
+// FindTwoFactorFailure returns the number of invalid two-factor codes of a signed in user within the current window
+func (r *Repository) FindTwoFactorFailure(userId int64) (int64, error) {
+	val, err := r.redis.Get(fmt.Sprintf("%s%d", constant.RedisTwoFactorFailurePrefix, userId))
+	if redis.IsNil(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, errk.Trace(err)
+	}
+
+	count, err := strconv.ParseInt(val, 10, 64)
+	if err != nil {
+		return 0, errk.Trace(err)
+	}
+
+	return count, nil
+}
+
+// DeleteTwoFactorFailure resets invalid two-factor code counter of a user
+func (r *Repository) DeleteTwoFactorFailure(userId int64) error {
+	_, err := r.redis.Del(fmt.Sprintf("%s%d", constant.RedisTwoFactorFailurePrefix, userId))
+	if err != nil {
+		return errk.Trace(err)
+	}
+	return nil
+}
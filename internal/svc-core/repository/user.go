@@ -1,6 +1,9 @@
 package repository
 
 import (
+	"encoding/json"
+
+	"github.com/konsultin/project-goes-here/dto"
 	"github.com/konsultin/project-goes-here/internal/svc-core/model"
 	"github.com/go-konsultin/errk"
 )
@@ -40,3 +43,54 @@ func (r *Repository) InsertUser(user *model.User) error {
 	}
 	return nil
 }
+
+// InsertUserWithCredentials creates a user with its credentials in one transaction, so a failed credential insert
+// does not leave a user holding the identifiers
+func (r *Repository) InsertUserWithCredentials(user *model.User, credentials []*model.UserCredential) error {
+	conn, err := r.db.GetConnection(r.ctx)
+	if err != nil {
+		return errk.Trace(err)
+	}
+	defer conn.Close()
+
+	tx, err := conn.BeginTxx(r.ctx, nil)
+	if err != nil {
+		return errk.Trace(err)
+	}
+
+	err = tx.NamedStmtContext(r.ctx, r.sql.User.Insert).GetContext(r.ctx, &user.Id, user)
+	if err != nil {
+		_ = tx.Rollback()
+		return errk.Trace(err)
+	}
+
+	for _, credential := range credentials {
+		credential.UserId = user.Id
+		err = tx.NamedStmtContext(r.ctx, r.sql.UserCredential.Insert).GetContext(r.ctx, &credential.Id, credential)
+		if err != nil {
+			_ = tx.Rollback()
+			return errk.Trace(err)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return errk.Trace(err)
+	}
+
+	return nil
+}
+
+// UpdateUserStatus updates status of a user, recording the subject modifying it
+func (r *Repository) UpdateUserStatus(id int64, statusId dto.ControlStatus_Enum, modifiedBy *model.Subject) error {
+	subject, err := json.Marshal(modifiedBy)
+	if err != nil {
+		return errk.Trace(err)
+	}
+
+	_, err = r.sql.User.UpdateStatus.ExecContext(r.ctx, statusId, subject, id)
+	if err != nil {
+		return errk.Trace(err)
+	}
+	return nil
+}
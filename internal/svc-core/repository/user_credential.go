@@ -44,6 +44,24 @@ func (r *Repository) UpdateCredentialSecret(id int64, newSecret string) error {
 	return nil
 }
 
+// UpdateCredentialVerified marks a credential as verified
+func (r *Repository) UpdateCredentialVerified(id int64) error {
+	_, err := r.sql.UserCredential.UpdateVerified.ExecContext(r.ctx, id)
+	if err != nil {
+		return errk.Trace(err)
+	}
+	return nil
+}
+
+// DeleteUserCredential deletes a user credential
+func (r *Repository) DeleteUserCredential(id int64) error {
+	_, err := r.sql.UserCredential.Delete.ExecContext(r.ctx, id)
+	if err != nil {
+		return errk.Trace(err)
+	}
+	return nil
+}
+
 // FindUserWithCredential finds user and their password credential by identifier
 func (r *Repository) FindUserWithCredential(identifier string) (*model.User, *model.UserCredential, error) {
 	user, err := r.FindUserByIdentifier(identifier)
@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"github.com/go-konsultin/errk"
+	"github.com/konsultin/project-goes-here/internal/svc-core/model"
+)
+
+// ReplaceUserRecoveryCodes deletes recovery codes of the user and inserts the new ones
+func (r *Repository) ReplaceUserRecoveryCodes(userId int64, codes []*model.UserRecoveryCode) error {
+	_, err := r.sql.UserRecoveryCode.DeleteByUser.ExecContext(r.ctx, userId)
+	if err != nil {
+		return errk.Trace(err)
+	}
+
+	for _, code := range codes {
+		err = r.sql.UserRecoveryCode.Insert.GetContext(r.ctx, &code.Id, code)
+		if err != nil {
+			return errk.Trace(err)
+		}
+	}
+
+	return nil
+}
+
+// UseUserRecoveryCode marks an unused recovery code of the user as used. Returns false if no unused code matches
+func (r *Repository) UseUserRecoveryCode(userId int64, codeHash string) (bool, error) {
+	result, err := r.sql.UserRecoveryCode.Use.ExecContext(r.ctx, userId, codeHash)
+	if err != nil {
+		return false, errk.Trace(err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, errk.Trace(err)
+	}
+
+	return affected == 1, nil
+}
+
+// DeleteUserRecoveryCodes deletes every recovery code of the user
+func (r *Repository) DeleteUserRecoveryCodes(userId int64) error {
+	_, err := r.sql.UserRecoveryCode.DeleteByUser.ExecContext(r.ctx, userId)
+	if err != nil {
+		return errk.Trace(err)
+	}
+	return nil
+}
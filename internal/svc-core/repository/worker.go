@@ -1,6 +1,10 @@
 package repository
 
 import (
+	"encoding/json"
+
+	"github.com/go-konsultin/errk"
+	"github.com/konsultin/project-goes-here/dto"
 	"github.com/konsultin/project-goes-here/internal/svc-core/constant"
 )
 
@@ -11,3 +15,18 @@ func (r *Repository) PublishExampleEvent(message string) error {
 	// Publish to NATS
 	return r.nats.Publish(constant.JobExample, []byte(message))
 }
+
+// PublishSecurityEvent notifies subscribers about suspicious activity on user credentials or sessions
+func (r *Repository) PublishSecurityEvent(event *dto.SecurityEvent) error {
+	if r.nats == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		return errk.Trace(err)
+	}
+
+	// Publish to NATS
+	return r.nats.Publish(constant.EventSecurity, data)
+}
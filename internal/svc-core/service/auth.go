@@ -7,16 +7,18 @@ import (
 	"time"
 
 	"github.com/konsultin/project-goes-here/dto"
+	specErr "github.com/konsultin/project-goes-here/internal/errors"
 	"github.com/konsultin/project-goes-here/internal/svc-core/model"
 	"github.com/konsultin/project-goes-here/internal/svc-core/pkg/httpk"
-	"github.com/konsultin/project-goes-here/internal/svc-core/pkg/oauth/google"
 	"github.com/go-konsultin/errk"
 	logkOption "github.com/go-konsultin/logk/option"
 	"github.com/go-konsultin/timek"
-	"golang.org/x/crypto/bcrypt"
 )
 
-// LoginWithPassword authenticates user with identifier (email/phone/username) and password
+// LoginWithPassword authenticates user with identifier (email/phone/username) and password.
+// Failed attempts are throttled by identifier and client IP, and lock the user temporarily after a threshold.
+// Throttled, locked and invalid attempts are all rejected with the same UnauthorizedError.
+// User with two-factor authentication enabled gets a challenge token to exchange for the session instead
 // Requires anonymous session bearer token for authentication
 func (s *Service) LoginWithPassword(payload *dto.LoginPassword_Payload) (*dto.CreateUserSession_Result_Data, error) {
 	// Verify anonymous session token first
@@ -30,29 +32,45 @@ func (s *Service) LoginWithPassword(payload *dto.LoginPassword_Payload) (*dto.Cr
 		identifier = strings.ToLower(identifier)
 	}
 
+	// Reject throttled attempt without checking password
+	throttled, err := s.isLoginThrottled(identifier)
+	if err != nil {
+		return nil, errk.Trace(err)
+	}
+	if throttled {
+		return nil, httpk.UnauthorizedError
+	}
+
 	// Find user by identifier
 	user, err := s.repo.FindUserByIdentifier(identifier)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			s.log.Warnf("User not found for identifier: %s", identifier)
-			return nil, httpk.UnauthorizedError
+			s.verifyDummyPassword(payload.Password)
+			return nil, s.failLogin(identifier, nil)
 		}
 		s.log.Error("Failed to find user", logkOption.Error(err))
 		return nil, errk.Trace(err)
 	}
 
-	// Check user status
-	if user.StatusId != dto.ControlStatus_ACTIVE {
-		s.log.Warnf("User account is not active. UserId=%d Status=%d", user.Id, user.StatusId)
-		return nil, httpk.ForbiddenError
+	// Locked user is rejected like an invalid password until its lockout has passed
+	if err = s.expireUserLockout(user); err != nil {
+		return nil, errk.Trace(err)
+	}
+	if user.StatusId == dto.ControlStatus_LOCKED {
+		s.log.Warnf("User account is locked. UserId=%d", user.Id)
+		s.verifyDummyPassword(payload.Password)
+		return nil, httpk.UnauthorizedError
 	}
 
-	// Find password credential for this user
+	// Find password credential for this user. User without password, e.g. signed up with OAuth, is never locked by
+	// failed password logins
 	credential, err := s.repo.FindCredentialByKey(dto.AuthProvider_PASSWORD, identifier)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			s.log.Warnf("No password credential found for identifier: %s", identifier)
-			return nil, httpk.UnauthorizedError
+			s.verifyDummyPassword(payload.Password)
+			return nil, s.failLogin(identifier, nil)
 		}
 		s.log.Error("Failed to find credential", logkOption.Error(err))
 		return nil, errk.Trace(err)
@@ -61,39 +79,200 @@ func (s *Service) LoginWithPassword(payload *dto.LoginPassword_Payload) (*dto.Cr
 	// Verify password
 	if !credential.CredentialSecret.Valid {
 		s.log.Warnf("Credential has no password set. CredentialId=%d", credential.Id)
-		return nil, httpk.UnauthorizedError
+		s.verifyDummyPassword(payload.Password)
+		return nil, s.failLogin(identifier, nil)
 	}
 
-	err = bcrypt.CompareHashAndPassword([]byte(credential.CredentialSecret.String), []byte(payload.Password))
-	if err != nil {
+	valid, needsRehash := s.verifyPassword(credential.CredentialSecret.String, payload.Password)
+	if !valid {
 		s.log.Warnf("Invalid password for identifier: %s", identifier)
-		return nil, httpk.UnauthorizedError
+		return nil, s.failLogin(identifier, user)
+	}
+
+	// Check user status once password is verified, so status is not revealed to who does not know the password.
+	// PENDING user has not verified its identifier yet
+	if user.StatusId == dto.ControlStatus_PENDING {
+		s.log.Warnf("User identifier is not verified. UserId=%d", user.Id)
+		return nil, specErr.IdentifierNotVerified
+	}
+	if user.StatusId != dto.ControlStatus_ACTIVE {
+		s.log.Warnf("User account is not active. UserId=%d Status=%d", user.Id, user.StatusId)
+		return nil, httpk.ForbiddenError
+	}
+
+	// Upgrade hash of outdated algorithm or parameters while the password is known
+	if needsRehash {
+		s.rehashPasswordCredentials(user.Id, credential.CredentialSecret.String, payload.Password)
+	}
+
+	// Require a verified email or phone if enabled
+	if s.config.RequireVerifiedIdentifier {
+		verified, err := s.isUserVerified(user.Id)
+		if err != nil {
+			return nil, errk.Trace(err)
+		}
+		if !verified {
+			s.log.Warnf("User identifier is not verified. UserId=%d", user.Id)
+			return nil, specErr.IdentifierNotVerified
+		}
+	}
+
+	// Complete login with a second factor if enabled
+	twoFactor, err := s.isTwoFactorEnabled(user)
+	if err != nil {
+		return nil, errk.Trace(err)
+	}
+	if twoFactor {
+		return s.createTwoFactorChallenge(user, dto.AuthProvider_PASSWORD, identifier, payload.Device)
+	}
+
+	if err = s.clearLoginFailure(identifier, user); err != nil {
+		return nil, errk.Trace(err)
 	}
 
 	// Create user session
 	return s.CreateUserSession(user, dto.AuthProvider_PASSWORD, payload.Device, time.Now())
 }
 
-// LoginWithGoogle authenticates user with Google OAuth
+// RegisterUser creates a new user with password credentials for every provided identifier
 // Requires anonymous session bearer token for authentication
-func (s *Service) LoginWithGoogle(payload *dto.LoginOAuth_Payload) (*dto.CreateUserSession_Result_Data, error) {
+func (s *Service) RegisterUser(payload *dto.RegisterUser_Payload) (*dto.CreateUserSession_Result_Data, error) {
 	// Verify anonymous session token first
 	if err := s.verifyAnonymousSession(); err != nil {
 		return nil, err
 	}
 
-	// Create Google provider
-	provider := google.NewProvider(s.config.GoogleClientID)
+	// Normalize identifiers (lowercase for email)
+	email := strings.ToLower(strings.TrimSpace(payload.Email))
+	phone := strings.TrimSpace(payload.Phone)
+	username := strings.TrimSpace(payload.Username)
+
+	var identifiers []string
+	for _, identifier := range []string{email, phone, username} {
+		if identifier != "" {
+			identifiers = append(identifiers, identifier)
+		}
+	}
+	if len(identifiers) == 0 {
+		return nil, specErr.IdentifierRequired
+	}
+
+	// Username can not be verified, so email or phone is required if verification is required
+	if s.config.RequireVerifiedIdentifier && email == "" && phone == "" {
+		return nil, specErr.IdentifierRequired
+	}
+
+	// Check identifiers uniqueness
+	for _, identifier := range identifiers {
+		taken, err := s.isIdentifierTaken(identifier)
+		if err != nil {
+			return nil, errk.Trace(err)
+		}
+		if taken {
+			s.log.Warnf("Identifier is already registered: %s", identifier)
+			return nil, specErr.IdentifierAlreadyRegistered
+		}
+	}
+
+	// Create user, it stays PENDING until an identifier is verified if verification is required
+	statusId := dto.ControlStatus_ACTIVE
+	if s.config.RequireVerifiedIdentifier {
+		statusId = dto.ControlStatus_PENDING
+	}
+
+	user := &model.User{
+		BaseField: model.NewBaseFieldFromModel(s.subject),
+		Xid:       s.generateXid(),
+		Username:  sql.NullString{String: username, Valid: username != ""},
+		FullName:  strings.TrimSpace(payload.FullName),
+		Email:     sql.NullString{String: email, Valid: email != ""},
+		Phone:     sql.NullString{String: phone, Valid: phone != ""},
+		StatusId:  statusId,
+	}
+
+	if err := s.validatePassword("password", payload.Password, user); err != nil {
+		return nil, err
+	}
 
-	// Verify Google token
-	userInfo, err := provider.VerifyToken(s.ctx, payload.IdToken)
+	// Hash password
+	hash, err := s.hashPassword(payload.Password)
 	if err != nil {
-		s.log.Error("Failed to verify Google token", logkOption.Error(err))
+		return nil, errk.Trace(err)
+	}
+
+	// Create one password credential per identifier
+	now := timek.Now()
+	credentials := make([]*model.UserCredential, 0, len(identifiers))
+	var verifiables []*model.UserCredential
+	for _, identifier := range identifiers {
+		credential := &model.UserCredential{
+			AuthProviderId:   dto.AuthProvider_PASSWORD,
+			CredentialKey:    identifier,
+			CredentialSecret: sql.NullString{String: hash, Valid: true},
+			CreatedAt:        now,
+			UpdatedAt:        now,
+		}
+		credentials = append(credentials, credential)
+
+		if identifier == email || identifier == phone {
+			verifiables = append(verifiables, credential)
+		}
+	}
+
+	// User and credentials are created together, a taken identifier must not leave a user behind
+	err = s.repo.InsertUserWithCredentials(user, credentials)
+	if err != nil {
+		s.log.Error("Failed to insert user with credentials", logkOption.Error(err))
+		return nil, errk.Trace(err)
+	}
+
+	// Send verification codes, user has to verify before creating session
+	if s.config.RequireVerifiedIdentifier {
+		for _, credential := range verifiables {
+			if err = s.sendVerificationCode(credential); err != nil {
+				return nil, errk.Trace(err)
+			}
+		}
+
+		return &dto.CreateUserSession_Result_Data{User: s.mustComposeUserResult(user)}, nil
+	}
+
+	// Create user session
+	return s.CreateUserSession(user, dto.AuthProvider_PASSWORD, payload.Device, time.Now())
+}
+
+// LoginWithOAuth authenticates user with the identity provider selected in payload
+// Requires anonymous session bearer token for authentication
+func (s *Service) LoginWithOAuth(payload *dto.LoginOAuth_Payload) (*dto.CreateUserSession_Result_Data, error) {
+	// Verify anonymous session token first
+	if err := s.verifyAnonymousSession(); err != nil {
+		return nil, err
+	}
+
+	// Resolve identity provider
+	provider, ok := s.oauthProviders.Get(payload.Provider)
+	if !ok {
+		s.log.Warnf("Auth provider is not supported. Provider=%d", payload.Provider)
+		return nil, specErr.UnsupportedAuthProvider
+	}
+
+	// Verify provider token
+	userInfo, err := provider.VerifyToken(s.ctx, payload)
+	if err != nil {
+		s.log.Errorf("Failed to verify %s token. Error=%v", provider.GetProviderName(), err)
 		return nil, httpk.UnauthorizedError.Wrap(err)
 	}
 
-	// Find existing credential for Google + user ID
-	credential, err := s.repo.FindCredentialByKey(dto.AuthProvider_GOOGLE, userInfo.ProviderId)
+	// Login with provider user
+	return s.loginWithOAuth(provider.GetProviderId(), userInfo, payload.Device)
+}
+
+// loginWithOAuth finds or creates the user of a verified OAuth identity and creates user session.
+// A new identity is linked to the existing user with the same email if both sides have verified it.
+// User with two-factor authentication enabled gets a challenge token to exchange for the session instead
+func (s *Service) loginWithOAuth(authProviderId dto.AuthProvider_Enum, userInfo *dto.OAuthUserInfo, device *dto.DeviceSession) (*dto.CreateUserSession_Result_Data, error) {
+	// Find existing credential for provider + user ID
+	credential, err := s.repo.FindCredentialByKey(authProviderId, userInfo.ProviderId)
 	if err != nil && !errors.Is(err, sql.ErrNoRows) {
 		s.log.Error("Failed to find credential", logkOption.Error(err))
 		return nil, errk.Trace(err)
@@ -109,22 +288,57 @@ func (s *Service) LoginWithGoogle(payload *dto.LoginOAuth_Payload) (*dto.CreateU
 			return nil, errk.Trace(err)
 		}
 	} else {
-		// New OAuth user - create user and credential
-		user, err = s.createGoogleUser(userInfo)
+		// Link to existing user with the same email
+		user, err = s.findUserToLink(authProviderId, userInfo)
 		if err != nil {
-			s.log.Error("Failed to create Google user", logkOption.Error(err))
-			return nil, errk.Trace(err)
+			return nil, err
+		}
+
+		if user == nil {
+			// New OAuth user - create user and credential
+			user, err = s.createOAuthUser(authProviderId, userInfo)
+			if err != nil {
+				s.log.Error("Failed to create OAuth user", logkOption.Error(err))
+				return nil, errk.Trace(err)
+			}
+
+			return s.CreateUserSession(user, authProviderId, device, time.Now())
 		}
 	}
 
-	// Check user status
+	// Check user status, before the identity is linked to it
+	if err = s.expireUserLockout(user); err != nil {
+		return nil, errk.Trace(err)
+	}
 	if user.StatusId != dto.ControlStatus_ACTIVE {
 		s.log.Warnf("User account is not active. UserId=%d Status=%d", user.Id, user.StatusId)
 		return nil, httpk.ForbiddenError
 	}
 
+	if credential == nil {
+		err = s.insertOAuthCredential(user, authProviderId, userInfo)
+		if err != nil {
+			s.log.Error("Failed to link OAuth credential", logkOption.Error(err))
+			return nil, errk.Trace(err)
+		}
+		s.log.Infof("OAuth identity linked by verified email. UserId=%d Provider=%d", user.Id, authProviderId)
+	}
+
+	// Complete login with a second factor if enabled, invalid codes count as failed logins of the email
+	twoFactor, err := s.isTwoFactorEnabled(user)
+	if err != nil {
+		return nil, errk.Trace(err)
+	}
+	if twoFactor {
+		identifier := user.Email.String
+		if identifier == "" {
+			identifier = userInfo.ProviderId
+		}
+		return s.createTwoFactorChallenge(user, authProviderId, identifier, device)
+	}
+
 	// Create user session
-	return s.CreateUserSession(user, dto.AuthProvider_GOOGLE, payload.Device, time.Now())
+	return s.CreateUserSession(user, authProviderId, device, time.Now())
 }
 
 // verifyAnonymousSession checks if request has valid anonymous session bearer token
@@ -154,30 +368,60 @@ func (s *Service) verifyAnonymousSession() error {
 	return nil
 }
 
-// createGoogleUser creates a new user from Google user info
-func (s *Service) createGoogleUser(userInfo *google.UserInfo) (*model.User, error) {
-	now := timek.Now()
+// loginClientId returns the client of the anonymous session a user logs in with, empty for other subjects
+func (s *Service) loginClientId() string {
+	if s.subject == nil {
+		return ""
+	}
+
+	switch s.subject.Role {
+	case dto.Role_Enum_name[int32(dto.Role_ANONYMOUS_USER)], dto.Role_Enum_name[int32(dto.Role_ANONYMOUS_ADMIN)]:
+		return s.subject.Id
+	default:
+		return ""
+	}
+}
+
+// createOAuthUser creates a new user from OAuth provider user info
+func (s *Service) createOAuthUser(authProviderId dto.AuthProvider_Enum, userInfo *dto.OAuthUserInfo) (*model.User, error) {
+	email := strings.ToLower(strings.TrimSpace(userInfo.Email))
 
 	// Create user
 	user := &model.User{
 		BaseField: model.NewBaseFieldFromModel(s.subject),
 		Xid:       s.generateXid(),
 		FullName:  userInfo.Name,
-		Email:     sql.NullString{String: userInfo.Email, Valid: userInfo.Email != ""},
+		Email:     sql.NullString{String: email, Valid: email != ""},
 		Avatar:    sql.NullString{String: userInfo.Picture, Valid: userInfo.Picture != ""},
 		StatusId:  dto.ControlStatus_ACTIVE,
 	}
 
-	// Insert user
-	err := s.repo.InsertUser(user)
+	// Insert user with its credential
+	credential := newOAuthCredential(user, authProviderId, userInfo)
+	err := s.repo.InsertUserWithCredentials(user, []*model.UserCredential{credential})
 	if err != nil {
 		return nil, errk.Trace(err)
 	}
 
-	// Create credential
+	return user, nil
+}
+
+// insertOAuthCredential attaches an OAuth identity to the user
+func (s *Service) insertOAuthCredential(user *model.User, authProviderId dto.AuthProvider_Enum, userInfo *dto.OAuthUserInfo) error {
+	err := s.repo.InsertUserCredential(newOAuthCredential(user, authProviderId, userInfo))
+	if err != nil {
+		return errk.Trace(err)
+	}
+
+	return nil
+}
+
+// newOAuthCredential creates the credential of an OAuth identity of the user
+func newOAuthCredential(user *model.User, authProviderId dto.AuthProvider_Enum, userInfo *dto.OAuthUserInfo) *model.UserCredential {
+	now := timek.Now()
 	credential := &model.UserCredential{
 		UserId:         user.Id,
-		AuthProviderId: dto.AuthProvider_GOOGLE,
+		AuthProviderId: authProviderId,
 		CredentialKey:  userInfo.ProviderId,
 		IsVerified:     userInfo.EmailVerified,
 		CreatedAt:      now,
@@ -188,10 +432,5 @@ func (s *Service) createGoogleUser(userInfo *google.UserInfo) (*model.User, erro
 		credential.VerifiedAt = sql.NullTime{Time: time.Now(), Valid: true}
 	}
 
-	err = s.repo.InsertUserCredential(credential)
-	if err != nil {
-		return nil, errk.Trace(err)
-	}
-
-	return user, nil
+	return credential
 }
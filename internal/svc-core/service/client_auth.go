@@ -0,0 +1,297 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/go-konsultin/errk"
+	logkOption "github.com/go-konsultin/logk/option"
+	"github.com/go-konsultin/timek"
+	"github.com/konsultin/project-goes-here/dto"
+	"github.com/konsultin/project-goes-here/internal/svc-core/constant"
+	"github.com/konsultin/project-goes-here/internal/svc-core/model"
+	"github.com/konsultin/project-goes-here/internal/svc-core/pkg/httpk"
+	"github.com/konsultin/project-goes-here/internal/svc-core/pkg/svck"
+	gonanoid "github.com/matoous/go-nanoid/v2"
+)
+
+// defaultClientTokenLifetime is the lifetime in seconds of tokens issued to a client when it is not set
+const defaultClientTokenLifetime = 2592000
+
+// CreateClientAuth creates a client with a generated client id and secret. The plain secret is only returned here
+func (s *Service) CreateClientAuth(payload *dto.CreateClientAuth_Payload) (*dto.ClientAuthSecret_Result, error) {
+	admin, err := s.verifyAdminSession(constant.PrivilegeManageClientAuth)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := generateSecretToken()
+	if err != nil {
+		s.log.Error("Failed to generate client secret", logkOption.Error(err))
+		return nil, errk.Trace(err)
+	}
+
+	hash, err := s.hashClientSecret(secret)
+	if err != nil {
+		return nil, errk.Trace(err)
+	}
+
+	tokenLifetime := payload.TokenLifetime
+	if tokenLifetime == 0 {
+		tokenLifetime = defaultClientTokenLifetime
+	}
+
+	clientAuth := &model.ClientAuth{
+		BaseField:    model.NewBaseFieldFromModel(s.subject),
+		Name:         payload.Name,
+		ClientId:     gonanoid.MustGenerate(svck.AlphaNumCharSet, 20),
+		ClientTypeId: payload.ClientTypeId,
+		Options: &model.ClientAuthOptions{
+			ClientSecret:  hash,
+			TokenLifetime: tokenLifetime,
+		},
+		StatusId: dto.ControlStatus_ACTIVE,
+	}
+
+	err = s.repo.InsertClientAuth(clientAuth)
+	if err != nil {
+		s.log.Error("Failed to InsertClientAuth", logkOption.Error(err))
+		return nil, errk.Trace(err)
+	}
+
+	s.log.Infof("Client created by admin. ClientId=%s AdminId=%s", clientAuth.ClientId, admin.Sub)
+
+	return &dto.ClientAuthSecret_Result{
+		Client:       composeClientAuthResult(clientAuth),
+		ClientSecret: secret,
+	}, nil
+}
+
+// ListClientAuths returns every client, secrets are never returned
+func (s *Service) ListClientAuths() (*dto.ListClientAuth_Result, error) {
+	_, err := s.verifyAdminSession(constant.PrivilegeManageClientAuth)
+	if err != nil {
+		return nil, err
+	}
+
+	clientAuths, err := s.repo.FindClientAuths()
+	if err != nil {
+		s.log.Error("Failed to FindClientAuths", logkOption.Error(err))
+		return nil, errk.Trace(err)
+	}
+
+	result := &dto.ListClientAuth_Result{
+		Clients: make([]*dto.ClientAuth, 0, len(clientAuths)),
+	}
+	for _, clientAuth := range clientAuths {
+		result.Clients = append(result.Clients, composeClientAuthResult(clientAuth))
+	}
+
+	return result, nil
+}
+
+// UpdateClientAuth updates name, token lifetime or status of a client. Only fields set in payload are changed
+func (s *Service) UpdateClientAuth(clientId string, payload *dto.UpdateClientAuth_Payload) (*dto.ClientAuth, error) {
+	admin, err := s.verifyAdminSession(constant.PrivilegeManageClientAuth)
+	if err != nil {
+		return nil, err
+	}
+
+	clientAuth, err := s.findClientAuth(clientId)
+	if err != nil {
+		return nil, err
+	}
+
+	if payload.Name != "" {
+		clientAuth.Name = payload.Name
+	}
+	if payload.TokenLifetime != 0 {
+		clientAuth.Options.TokenLifetime = payload.TokenLifetime
+	}
+
+	statusId := clientAuth.StatusId
+	if payload.StatusId != 0 {
+		statusId = payload.StatusId
+	}
+
+	err = s.updateClientAuth(clientAuth, statusId)
+	if err != nil {
+		return nil, err
+	}
+
+	s.log.Infof("Client updated by admin. ClientId=%s AdminId=%s", clientAuth.ClientId, admin.Sub)
+
+	return composeClientAuthResult(clientAuth), nil
+}
+
+// DeactivateClientAuth stops a client from creating sessions, tokens already issued to it are rejected
+func (s *Service) DeactivateClientAuth(clientId string) error {
+	admin, err := s.verifyAdminSession(constant.PrivilegeManageClientAuth)
+	if err != nil {
+		return err
+	}
+
+	clientAuth, err := s.findClientAuth(clientId)
+	if err != nil {
+		return err
+	}
+
+	err = s.updateClientAuth(clientAuth, dto.ControlStatus_INACTIVE)
+	if err != nil {
+		return err
+	}
+
+	s.log.Infof("Client deactivated by admin. ClientId=%s AdminId=%s", clientAuth.ClientId, admin.Sub)
+
+	return nil
+}
+
+// RotateClientSecret replaces the secret of a client and returns the new plain secret. The previous secret is still
+// accepted during the rotation overlap, so the client can be redeployed with the new secret without downtime
+func (s *Service) RotateClientSecret(clientId string) (*dto.ClientAuthSecret_Result, error) {
+	admin, err := s.verifyAdminSession(constant.PrivilegeManageClientAuth)
+	if err != nil {
+		return nil, err
+	}
+
+	clientAuth, err := s.findClientAuth(clientId)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := generateSecretToken()
+	if err != nil {
+		s.log.Error("Failed to generate client secret", logkOption.Error(err))
+		return nil, errk.Trace(err)
+	}
+
+	hash, err := s.hashClientSecret(secret)
+	if err != nil {
+		return nil, errk.Trace(err)
+	}
+
+	// Only the secret replaced by this rotation stays valid, a secret of an earlier rotation is dropped
+	now := time.Now()
+	options := clientAuth.Options
+	options.PreviousClientSecret = ""
+	options.PreviousClientSecretExpiredAt = 0
+	if overlap := s.config.ClientSecretRotationOverlap; overlap > 0 {
+		options.PreviousClientSecret = options.ClientSecret
+		options.PreviousClientSecretExpiredAt = now.Add(time.Duration(overlap) * time.Second).Unix()
+	}
+	options.ClientSecret = hash
+	options.ClientSecretRotatedAt = now.Unix()
+
+	err = s.updateClientAuth(clientAuth, clientAuth.StatusId)
+	if err != nil {
+		return nil, err
+	}
+
+	s.log.Infof("Client secret rotated by admin. ClientId=%s AdminId=%s", clientAuth.ClientId, admin.Sub)
+
+	return &dto.ClientAuthSecret_Result{
+		Client:       composeClientAuthResult(clientAuth),
+		ClientSecret: secret,
+	}, nil
+}
+
+// findClientAuth returns the client by its client id, NotFoundError if it does not exist
+func (s *Service) findClientAuth(clientId string) (*model.ClientAuth, error) {
+	clientAuth, err := s.repo.FindClientAuthByClientId(clientId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			s.log.Warnf("Client is not found. ClientId=%s", clientId)
+			return nil, httpk.NotFoundError
+		}
+		s.log.Error("Failed to FindClientAuthByClientId", logkOption.Error(err))
+		return nil, errk.Trace(err)
+	}
+	return clientAuth, nil
+}
+
+// updateClientAuth saves the client with the status. Tokens of a deactivated client are rejected until the longest
+// token it may have been issued has expired, reactivating the client accepts them again
+func (s *Service) updateClientAuth(clientAuth *model.ClientAuth, statusId dto.ControlStatus_Enum) error {
+	prevStatusId := clientAuth.StatusId
+	clientAuth.StatusId = statusId
+	clientAuth.ModifiedBy = s.subject
+
+	err := s.repo.UpdateClientAuth(clientAuth)
+	if err != nil {
+		s.log.Error("Failed to UpdateClientAuth", logkOption.Error(err))
+		return errk.Trace(err)
+	}
+	clientAuth.Version++
+	clientAuth.UpdatedAt = timek.Now()
+
+	switch {
+	case statusId != dto.ControlStatus_ACTIVE:
+		err = s.repo.InsertInactiveClient(clientAuth.ClientId, time.Duration(clientAuth.Options.TokenLifetime)*time.Second)
+		if err != nil {
+			s.log.Error("Failed to InsertInactiveClient", logkOption.Error(err))
+			return errk.Trace(err)
+		}
+	case prevStatusId != dto.ControlStatus_ACTIVE:
+		err = s.repo.DeleteInactiveClient(clientAuth.ClientId)
+		if err != nil {
+			s.log.Error("Failed to DeleteInactiveClient", logkOption.Error(err))
+			return errk.Trace(err)
+		}
+	}
+
+	return nil
+}
+
+// verifyClientSecret checks the secret against the current secret of the client, or the previous one during rotation
+// overlap. An outdated hash of the current secret is upgraded
+func (s *Service) verifyClientSecret(clientAuth *model.ClientAuth, secret string) bool {
+	options := clientAuth.Options
+
+	valid, needsRehash := s.verifyClientSecretHash(options.ClientSecret, secret)
+	if valid {
+		// Upgrade hash of outdated algorithm or parameters while the secret is known
+		if needsRehash {
+			s.rehashClientSecret(clientAuth, secret)
+		}
+		return true
+	}
+
+	if options.PreviousClientSecret == "" || time.Now().Unix() >= options.PreviousClientSecretExpiredAt {
+		return false
+	}
+
+	valid, _ = s.verifyClientSecretHash(options.PreviousClientSecret, secret)
+	if valid {
+		s.log.Infof("Client authenticated with previous secret. ClientId=%s", clientAuth.ClientId)
+	}
+
+	return valid
+}
+
+// composeClientAuthResult creates a ClientAuth DTO, secrets are left out
+func composeClientAuthResult(m *model.ClientAuth) *dto.ClientAuth {
+	result := &dto.ClientAuth{
+		ClientId: m.ClientId,
+		Name:     m.Name,
+		ClientType: &dto.ClientType_Result{
+			Id:   m.ClientTypeId,
+			Name: dto.Role_Enum_name[int32(m.ClientTypeId)],
+		},
+		Status:     composeControlStatusResult(m.StatusId),
+		ModifiedBy: model.ToSubjectResult(m.ModifiedBy),
+		CreatedAt:  m.CreatedAt.ToTime().Unix(),
+		UpdatedAt:  m.UpdatedAt.ToTime().Unix(),
+		Version:    m.Version,
+	}
+
+	if m.Options != nil {
+		result.TokenLifetime = m.Options.TokenLifetime
+		result.ClientSecretRotatedAt = m.Options.ClientSecretRotatedAt
+		if m.Options.PreviousClientSecret != "" {
+			result.PreviousClientSecretExpiredAt = m.Options.PreviousClientSecretExpiredAt
+		}
+	}
+
+	return result
+}
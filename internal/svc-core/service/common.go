@@ -4,7 +4,12 @@ import (
 	"context"
 
 	"github.com/konsultin/project-goes-here/config"
+	"github.com/konsultin/project-goes-here/dto"
 	"github.com/konsultin/project-goes-here/internal/svc-core/model"
+	"github.com/konsultin/project-goes-here/internal/svc-core/pkg/oauth"
+	"github.com/konsultin/project-goes-here/internal/svc-core/pkg/hasher"
+	"github.com/konsultin/project-goes-here/internal/svc-core/pkg/pwpolicy"
+	"github.com/konsultin/project-goes-here/internal/svc-core/pkg/sender"
 	"github.com/konsultin/project-goes-here/internal/svc-core/repository"
 	"github.com/go-konsultin/logk"
 	logkOption "github.com/go-konsultin/logk/option"
@@ -16,6 +21,16 @@ type Service struct {
 	ctx     context.Context
 	config  *config.Config
 	subject *model.Subject
+
+	clientIp string
+
+	oauthProviders *oauth.Registry
+	jwtKeyring     *JwtKeyring
+	sender         sender.Sender
+	hasher         *hasher.Policy
+	passwordPolicy *pwpolicy.Policy
+
+	clientSecretHasher *hasher.Policy
 }
 
 func (s *Service) WithSubject(subject *model.Subject) *Service {
@@ -42,6 +57,53 @@ func (s *Service) WithRepo(repo *repository.Repository) *Service {
 	return &newS
 }
 
+func (s *Service) WithClientIp(clientIp string) *Service {
+	newS := *s
+	newS.clientIp = clientIp
+	return &newS
+}
+
+func (s *Service) WithOAuthProviders(registry *oauth.Registry) *Service {
+	newS := *s
+	newS.oauthProviders = registry
+	return &newS
+}
+
+func (s *Service) WithJwtKeyring(keyring *JwtKeyring) *Service {
+	newS := *s
+	newS.jwtKeyring = keyring
+	return &newS
+}
+
+func (s *Service) WithSender(sender sender.Sender) *Service {
+	newS := *s
+	newS.sender = sender
+	return &newS
+}
+
+func (s *Service) WithHasher(hasher *hasher.Policy) *Service {
+	newS := *s
+	newS.hasher = hasher
+	return &newS
+}
+
+func (s *Service) WithClientSecretHasher(hasher *hasher.Policy) *Service {
+	newS := *s
+	newS.clientSecretHasher = hasher
+	return &newS
+}
+
+func (s *Service) WithPasswordPolicy(policy *pwpolicy.Policy) *Service {
+	newS := *s
+	newS.passwordPolicy = policy
+	return &newS
+}
+
+// Jwks returns the public keys used to verify issued JWT
+func (s *Service) Jwks() *dto.Jwks {
+	return s.jwtKeyring.Jwks()
+}
+
 func (s *Service) WithLog(log logk.Logger) *Service {
 	newS := *s
 	newS.log = log
@@ -0,0 +1,223 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/go-konsultin/errk"
+	logkOption "github.com/go-konsultin/logk/option"
+	"github.com/konsultin/project-goes-here/dto"
+	specErr "github.com/konsultin/project-goes-here/internal/errors"
+	"github.com/konsultin/project-goes-here/internal/svc-core/model"
+	"github.com/konsultin/project-goes-here/internal/svc-core/pkg/httpk"
+)
+
+// LinkOAuth attaches an OAuth identity to current user, so the user can sign in with it
+func (s *Service) LinkOAuth(payload *dto.LinkOAuth_Payload) error {
+	claims, err := s.verifyOwnUserSession()
+	if err != nil {
+		return err
+	}
+
+	user, err := s.getUserByXid(claims.Sub)
+	if err != nil {
+		return errk.Trace(err)
+	}
+
+	// Resolve identity provider
+	provider, ok := s.oauthProviders.Get(payload.Provider)
+	if !ok {
+		s.log.Warnf("Auth provider is not supported. Provider=%d", payload.Provider)
+		return specErr.UnsupportedAuthProvider
+	}
+
+	// Verify provider token
+	userInfo, err := provider.VerifyToken(s.ctx, &dto.LoginOAuth_Payload{
+		Provider: payload.Provider,
+		IdToken:  payload.IdToken,
+		Nonce:    payload.Nonce,
+	})
+	if err != nil {
+		s.log.Errorf("Failed to verify %s token. Error=%v", provider.GetProviderName(), err)
+		return httpk.UnauthorizedError.Wrap(err)
+	}
+
+	// Identity can sign in to a single user
+	_, err = s.repo.FindCredentialByKey(provider.GetProviderId(), userInfo.ProviderId)
+	if err == nil {
+		s.log.Warnf("OAuth identity is already linked. UserId=%d Provider=%d", user.Id, provider.GetProviderId())
+		return specErr.IdentityAlreadyLinked
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		s.log.Error("Failed to find credential", logkOption.Error(err))
+		return errk.Trace(err)
+	}
+
+	// User links one identity per provider
+	credentials, err := s.repo.FindCredentialsByUserId(user.Id)
+	if err != nil {
+		s.log.Error("Failed to FindCredentialsByUserId", logkOption.Error(err))
+		return errk.Trace(err)
+	}
+	for _, credential := range credentials {
+		if credential.AuthProviderId == provider.GetProviderId() {
+			s.log.Warnf("User has linked another identity of the provider. UserId=%d Provider=%d", user.Id, provider.GetProviderId())
+			return specErr.IdentityAlreadyLinked
+		}
+	}
+
+	err = s.insertOAuthCredential(user, provider.GetProviderId(), userInfo)
+	if err != nil {
+		s.log.Error("Failed to link OAuth credential", logkOption.Error(err))
+		return errk.Trace(err)
+	}
+
+	s.log.Infof("OAuth identity linked. UserId=%d Provider=%d", user.Id, provider.GetProviderId())
+
+	return nil
+}
+
+// UnlinkOAuth removes the OAuth identity of the provider from current user.
+// The last credential user can sign in with can not be removed
+func (s *Service) UnlinkOAuth(providerName string) error {
+	claims, err := s.verifyOwnUserSession()
+	if err != nil {
+		return err
+	}
+
+	authProviderId := dto.AuthProvider_Enum(dto.AuthProvider_Enum_value[strings.ToUpper(providerName)])
+	if !isOAuthProvider(authProviderId) {
+		s.log.Warnf("Auth provider is not an OAuth provider: %s", providerName)
+		return specErr.UnsupportedAuthProvider
+	}
+
+	user, err := s.getUserByXid(claims.Sub)
+	if err != nil {
+		return errk.Trace(err)
+	}
+
+	credentials, err := s.repo.FindCredentialsByUserId(user.Id)
+	if err != nil {
+		s.log.Error("Failed to FindCredentialsByUserId", logkOption.Error(err))
+		return errk.Trace(err)
+	}
+
+	var target *model.UserCredential
+	var remaining int
+	for _, credential := range credentials {
+		switch {
+		case credential.AuthProviderId == authProviderId:
+			target = credential
+		case isSignInCredential(credential):
+			remaining++
+		}
+	}
+
+	if target == nil {
+		s.log.Warnf("No linked identity of the provider. UserId=%d Provider=%d", user.Id, authProviderId)
+		return httpk.NotFoundError
+	}
+	if remaining == 0 {
+		s.log.Warnf("Refused to unlink the last credential. UserId=%d Provider=%d", user.Id, authProviderId)
+		return specErr.LastCredential
+	}
+
+	err = s.repo.DeleteUserCredential(target.Id)
+	if err != nil {
+		s.log.Error("Failed to DeleteUserCredential", logkOption.Error(err))
+		return errk.Trace(err)
+	}
+
+	s.log.Infof("OAuth identity unlinked. UserId=%d Provider=%d", user.Id, authProviderId)
+
+	return nil
+}
+
+// findUserToLink returns the existing user with the email of an OAuth identity, nil if email is not registered.
+// Linking requires the email to be verified by both a provider asserting it and the user, otherwise the identity
+// is refused as the email can not be reused by a new user
+func (s *Service) findUserToLink(authProviderId dto.AuthProvider_Enum, userInfo *dto.OAuthUserInfo) (*model.User, error) {
+	email := strings.ToLower(strings.TrimSpace(userInfo.Email))
+	if email == "" {
+		return nil, nil
+	}
+
+	user, err := s.repo.FindUserByIdentifier(email)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		s.log.Error("Failed to find user", logkOption.Error(err))
+		return nil, errk.Trace(err)
+	}
+
+	// Private relay address is not the user's mailbox, and unverified email proves nothing
+	if !isEmailAsserted(authProviderId) || !userInfo.EmailVerified || userInfo.IsPrivateEmail {
+		s.log.Warnf("OAuth email is registered but can not be linked. UserId=%d", user.Id)
+		return nil, specErr.IdentifierAlreadyRegistered
+	}
+
+	// Prevent taking over an account registered with someone else's unverified email
+	verified, err := s.isEmailVerifiedByUser(user, email)
+	if err != nil {
+		return nil, errk.Trace(err)
+	}
+	if !verified {
+		s.log.Warnf("Email of existing user is not verified, sign in and link manually. UserId=%d", user.Id)
+		return nil, specErr.IdentifierAlreadyRegistered
+	}
+
+	return user, nil
+}
+
+// isEmailVerifiedByUser checks if user has verified its email, with a code sent to it or by a verified OAuth sign up
+func (s *Service) isEmailVerifiedByUser(user *model.User, email string) (bool, error) {
+	credentials, err := s.repo.FindCredentialsByUserId(user.Id)
+	if err != nil {
+		s.log.Error("Failed to FindCredentialsByUserId", logkOption.Error(err))
+		return false, errk.Trace(err)
+	}
+
+	for _, credential := range credentials {
+		if credential.AuthProviderId == dto.AuthProvider_PASSWORD && credential.CredentialKey == email {
+			return credential.IsVerified, nil
+		}
+	}
+
+	// User signed up with OAuth, its email comes from a provider that verified it
+	for _, credential := range credentials {
+		if isEmailAsserted(credential.AuthProviderId) && credential.IsVerified {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// isOAuthProvider checks if auth provider is an external identity provider
+func isOAuthProvider(authProviderId dto.AuthProvider_Enum) bool {
+	switch authProviderId {
+	case dto.AuthProvider_GOOGLE, dto.AuthProvider_FACEBOOK, dto.AuthProvider_APPLE:
+		return true
+	}
+	return false
+}
+
+// isEmailAsserted checks if auth provider asserts the email of its identities has been verified, so it can be used
+// to link accounts. Facebook does not
+func isEmailAsserted(authProviderId dto.AuthProvider_Enum) bool {
+	switch authProviderId {
+	case dto.AuthProvider_GOOGLE, dto.AuthProvider_APPLE:
+		return true
+	}
+	return false
+}
+
+// isSignInCredential checks if user can sign in with the credential by itself, unlike a second factor
+func isSignInCredential(credential *model.UserCredential) bool {
+	if credential.AuthProviderId == dto.AuthProvider_PASSWORD {
+		return credential.CredentialSecret.Valid
+	}
+	return isOAuthProvider(credential.AuthProviderId)
+}
@@ -0,0 +1,248 @@
+package service
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/go-konsultin/errk"
+	logkOption "github.com/go-konsultin/logk/option"
+	"github.com/go-konsultin/sqlk"
+	"github.com/go-konsultin/timek"
+	"github.com/konsultin/project-goes-here/dto"
+	specErr "github.com/konsultin/project-goes-here/internal/errors"
+	"github.com/konsultin/project-goes-here/internal/svc-core/constant"
+	"github.com/konsultin/project-goes-here/internal/svc-core/model"
+	"github.com/konsultin/project-goes-here/internal/svc-core/pkg/httpk"
+	"github.com/konsultin/project-goes-here/internal/svc-core/pkg/svck"
+	"github.com/konsultin/project-goes-here/internal/svc-core/pkg/valk"
+	gonanoid "github.com/matoous/go-nanoid/v2"
+)
+
+// Reasons of impersonation END events
+const (
+	impersonationEndLogout          = "logout"
+	impersonationEndLogoutAll       = "logout_all"
+	impersonationEndRevokedByUser   = "revoked_by_user"
+	impersonationEndRevokedByAdmin  = "revoked_by_admin"
+	impersonationEndRevokedByClient = "revoked_by_client"
+	impersonationEndPasswordChange  = "password_changed"
+	impersonationEndPasswordReset   = "password_reset"
+)
+
+// StartImpersonation creates a short-lived session of the user for the admin, so support can reproduce problems as
+// the user. The access token carries the admin in act claim, writes made with it are recorded as modified by the admin.
+// The session has no refresh token, and the start is recorded in impersonation audit trail before the token is issued
+func (s *Service) StartImpersonation(userXid string, payload *dto.StartImpersonation_Payload) (*dto.StartImpersonation_Result, error) {
+	admin, err := s.verifyAdminSession(constant.PrivilegeImpersonateUser)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.getUserByXid(userXid)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, httpk.NotFoundError
+		}
+		return nil, errk.Trace(err)
+	}
+	if user.StatusId != dto.ControlStatus_ACTIVE {
+		s.log.Warnf("Impersonated user is not active. UserXid=%s Status=%d", user.Xid, user.StatusId)
+		return nil, httpk.ForbiddenError
+	}
+
+	audience, err := s.impersonationAudience()
+	if err != nil {
+		return nil, err
+	}
+	if len(audience) == 0 {
+		s.log.Warn("Impersonation is disabled, no privilege is allowed in IMPERSONATION_PRIVILEGES")
+		return nil, httpk.ForbiddenError
+	}
+
+	createdAt := time.Now()
+	sessionId := gonanoid.MustGenerate(svck.AlphaNumUpperCharSet, 10)
+	subjectType := int32(dto.Role_USER)
+
+	jwtAdapter := s.NewJwtAdapter()
+	accessSession, err := jwtAdapter.Issue(IssueJwtPayload{
+		SessionId:   sessionId,
+		Subject:     user.Xid,
+		Audience:    audience,
+		Lifetime:    s.config.ImpersonationSessionLifetime,
+		SubjectType: subjectType,
+		CreatedAt:   sql.NullTime{Time: createdAt, Valid: true},
+		Actor:       admin.Sub,
+		ClientId:    admin.ClientId,
+	})
+	if err != nil {
+		s.log.Error("Failed to issue jwt payload", logkOption.Error(err))
+		return nil, errk.Trace(err)
+	}
+
+	actor := &model.Subject{
+		Id:       admin.Sub,
+		Role:     dto.Role_Enum_name[int32(dto.Role_ADMIN)],
+		FullName: s.subject.FullName,
+	}
+
+	metadata, err := json.Marshal(model.ImpersonationAuditMetadata{
+		Privileges: audience,
+		ExpiredAt:  accessSession.ExpiredAt,
+	})
+	if err != nil {
+		return nil, errk.Trace(err)
+	}
+
+	// Audit before the session exists, so there is no session of the user without a trail
+	err = s.insertImpersonationAudit(&model.ImpersonationAudit{
+		SessionXid: sessionId,
+		Event:      constant.ImpersonationEventStart,
+		AdminId:    admin.Sub,
+		UserXid:    user.Xid,
+		Reason:     payload.Reason,
+		Actor:      actor,
+		Metadata:   metadata,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	authSession := &model.AuthSession{
+		BaseField:       model.NewBaseFieldFromModel(actor),
+		Xid:             sessionId,
+		FamilyId:        sessionId,
+		SubjectId:       user.Xid,
+		SubjectTypeId:   dto.Role_Enum(subjectType),
+		SubjectFullName: user.FullName,
+		AuthProviderId:  dto.AuthProvider_UNKNOWN,
+		Device: &model.AuthSessionDevice{
+			ClientIp: s.clientIp,
+		},
+		ExpiredAt: time.Unix(accessSession.ExpiredAt, 0),
+		StatusId:  dto.ControlStatus_ACTIVE,
+		Actor:     actor,
+	}
+
+	err = s.repo.InsertAuthSession(authSession)
+	if err != nil {
+		s.log.Error("Failed to InsertAuthSession", logkOption.Error(err))
+		return nil, errk.Trace(err)
+	}
+
+	s.log.Infof("Impersonation started by admin. SubjectId=%s AdminId=%s SessionXid=%s", user.Xid, admin.Sub, sessionId)
+
+	return &dto.StartImpersonation_Result{
+		User:          s.mustComposeUserResult(user),
+		AccessSession: accessSession,
+		AccessScopes:  audience,
+	}, nil
+}
+
+// impersonationAudience returns privileges of USER role granted to impersonation sessions, only those listed in the
+// configured impersonation privileges. Refreshing is never granted
+func (s *Service) impersonationAudience() ([]string, error) {
+	rolePrivileges, err := s.repo.FindRolePrivilegeByRoleId(int32(dto.Role_USER))
+	if err != nil {
+		s.log.Error("Failed to FindRolePrivilegeByRoleId", logkOption.Error(err))
+		return nil, errk.Trace(err)
+	}
+
+	allowed := s.config.ImpersonationPrivileges
+	audience := make([]string, 0, len(rolePrivileges))
+	for _, val := range rolePrivileges {
+		xid := val.Privilege.Xid
+		if xid == constant.PrivilegeRefreshUserToken {
+			continue
+		}
+		if !valk.InArrayString(xid, allowed) {
+			continue
+		}
+		audience = append(audience, xid)
+	}
+
+	return audience, nil
+}
+
+// verifyOwnUserSession checks the request like verifyUserSession, and rejects impersonation sessions. Used by actions
+// securing the account, an admin acting as the user must not be able to take over the account
+func (s *Service) verifyOwnUserSession() (*JwtResponse, error) {
+	claims, err := s.verifyUserSession()
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.Act != nil {
+		s.log.Warnf("Action is not allowed in impersonation session. SubjectId=%s AdminId=%s", claims.Sub, claims.Act.Sub)
+		return nil, specErr.ImpersonationNotAllowed
+	}
+
+	return claims, nil
+}
+
+// deleteSessionsBySubjectId deletes every session of the subject, recording the end of its impersonation sessions
+func (s *Service) deleteSessionsBySubjectId(subjectId string, reason string) error {
+	sessions, err := s.repo.FindSessionsBySubjectId(subjectId)
+	if err != nil {
+		s.log.Error("Failed to FindSessionsBySubjectId", logkOption.Error(err))
+		return errk.Trace(err)
+	}
+
+	for _, session := range sessions {
+		err = s.auditImpersonationEnd(session, reason)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = s.repo.DeleteSessionsBySubjectId(subjectId)
+	if err != nil {
+		s.log.Error("Failed to DeleteSessionsBySubjectId", logkOption.Error(err))
+		return errk.Trace(err)
+	}
+
+	return nil
+}
+
+// auditImpersonationEnd records the end of an impersonation session before it is deleted. Sessions created by the
+// subject are skipped. Expired sessions are not recorded, their end is the expiredAt of the START event
+func (s *Service) auditImpersonationEnd(session *model.AuthSession, reason string) error {
+	if session.Actor == nil {
+		return nil
+	}
+
+	err := s.insertImpersonationAudit(&model.ImpersonationAudit{
+		SessionXid: session.Xid,
+		Event:      constant.ImpersonationEventEnd,
+		AdminId:    session.Actor.Id,
+		UserXid:    session.SubjectId,
+		Reason:     reason,
+		Actor:      s.subject,
+	})
+	if err != nil {
+		return err
+	}
+
+	s.log.Infof("Impersonation ended. SubjectId=%s AdminId=%s SessionXid=%s Reason=%s",
+		session.SubjectId, session.Actor.Id, session.Xid, reason)
+
+	return nil
+}
+
+// insertImpersonationAudit appends the event to impersonation audit trail, from the client ip of the request
+func (s *Service) insertImpersonationAudit(m *model.ImpersonationAudit) error {
+	m.ClientIp = s.clientIp
+	m.CreatedAt = timek.Now()
+	if m.Metadata == nil {
+		m.Metadata = sqlk.EmptyObjectJSON
+	}
+
+	err := s.repo.InsertImpersonationAudit(m)
+	if err != nil {
+		s.log.Error("Failed to InsertImpersonationAudit", logkOption.Error(err))
+		return errk.Trace(err)
+	}
+
+	return nil
+}
@@ -17,9 +17,8 @@ import (
 )
 
 type JwtAdapter struct {
-	Algorithm *jwt.SigningMethodHMAC
-	Issuer    string
-	Secret    string
+	Issuer  string
+	Keyring *JwtKeyring
 }
 
 type IssueJwtPayload struct {
@@ -29,7 +28,11 @@ type IssueJwtPayload struct {
 	SessionId   string
 	SubjectType int32
 	CreatedAt   sql.NullTime
-	metadata    map[string]string
+	// Actor is set when the token is issued to an admin acting as the subject
+	Actor string
+	// ClientId is the client a user session is issued through, RFC 9068 section 2.2
+	ClientId string
+	metadata map[string]string
 }
 
 type JwtResponse struct {
@@ -41,13 +44,20 @@ type JwtResponse struct {
 	Jti  string                 `json:"jti"`
 	Sub  string                 `json:"sub"`
 	Meta map[string]interface{} `json:"meta"`
+	Act  *JwtActor              `json:"act,omitempty"`
+	// ClientId is empty on tokens of anonymous and service clients, their subject is the client
+	ClientId string `json:"client_id,omitempty"`
+}
+
+// JwtActor is the party acting on behalf of the subject, RFC 8693 section 4.1
+type JwtActor struct {
+	Sub string `json:"sub"`
 }
 
 func (s *Service) NewJwtAdapter() *JwtAdapter {
 	return &JwtAdapter{
-		Algorithm: jwt.SigningMethodHS512,
-		Issuer:    s.config.JwtIssuer,
-		Secret:    s.config.JwtSecret,
+		Issuer:  s.config.JwtIssuer,
+		Keyring: s.jwtKeyring,
 	}
 }
 
@@ -64,8 +74,11 @@ func (ja *JwtAdapter) Issue(options IssueJwtPayload) (*dto.Session, error) {
 	exp := createdAt.Add(time.Second * time.Duration(options.Lifetime)).Unix()
 
 	// prepare signing token
-	signingMethod := ja.Algorithm
-	token := jwt.New(signingMethod)
+	signingKey := ja.Keyring.SigningKey()
+	token := jwt.New(signingKey.Method)
+	if signingKey.Kid != "" {
+		token.Header["kid"] = signingKey.Kid
+	}
 	claims := token.Claims.(jwt.MapClaims)
 	claims["exp"] = exp
 	claims["iss"] = ja.Issuer
@@ -75,9 +88,15 @@ func (ja *JwtAdapter) Issue(options IssueJwtPayload) (*dto.Session, error) {
 	claims["iat"] = createdAt.Unix()
 	claims["ent"] = options.SubjectType
 	claims["meta"] = options.metadata
+	if options.Actor != "" {
+		claims["act"] = JwtActor{Sub: options.Actor}
+	}
+	if options.ClientId != "" {
+		claims["client_id"] = options.ClientId
+	}
 
 	// create string token
-	tokenString, err := token.SignedString([]byte(ja.Secret))
+	tokenString, err := token.SignedString(signingKey.SignKey)
 	if err != nil {
 		logk.Get().Error("failed to signedString for JWT Token", logkOption.Error(err))
 		return nil, errk.Trace(err)
@@ -90,32 +109,11 @@ func (ja *JwtAdapter) Issue(options IssueJwtPayload) (*dto.Session, error) {
 }
 
 func (ja *JwtAdapter) Validate(token string, options *dto.ValidateJwt_Payload) (*JwtResponse, error) {
-	keyFunc := func(token *jwt.Token) (interface{}, error) {
-		_, ok := token.Method.(*jwt.SigningMethodHMAC)
-		if !ok {
-			return nil, errk.Trace(fmt.Errorf("token method is invalid"))
-		}
-		return []byte(ja.Secret), nil
-	}
-
-	mapClaims := jwt.MapClaims{}
-	_, err := jwt.ParseWithClaims(token, &mapClaims, keyFunc)
+	claims, err := ja.parse(token)
 	if err != nil {
 		return nil, err
 	}
 
-	// convert map claims to json
-	jsonData, err := json.Marshal(mapClaims)
-	if err != nil {
-		return nil, errk.Trace(err)
-	}
-
-	var claims JwtResponse
-	err = json.Unmarshal(jsonData, &claims)
-	if err != nil {
-		return nil, errk.Trace(err)
-	}
-
 	isValid := false
 	for _, val := range options.Audience {
 		if valk.InArrayString(val, claims.Aud) {
@@ -130,17 +128,27 @@ func (ja *JwtAdapter) Validate(token string, options *dto.ValidateJwt_Payload) (
 		))
 	}
 
-	return &claims, nil
+	return claims, nil
 }
 
 // ValidateWithoutAudience validates JWT token without checking audience
 func (ja *JwtAdapter) ValidateWithoutAudience(token string) (*JwtResponse, error) {
+	return ja.parse(token)
+}
+
+// parse verifies token signature with the key selected by kid header and returns the claims
+func (ja *JwtAdapter) parse(token string) (*JwtResponse, error) {
 	keyFunc := func(token *jwt.Token) (interface{}, error) {
-		_, ok := token.Method.(*jwt.SigningMethodHMAC)
+		kid, _ := token.Header["kid"].(string)
+		key, ok := ja.Keyring.VerificationKey(kid)
 		if !ok {
+			return nil, errk.Trace(fmt.Errorf("token key is unknown. kid=%s", kid))
+		}
+		// Reject algorithm confusion, token must use the algorithm of its key
+		if token.Method.Alg() != key.Method.Alg() {
 			return nil, errk.Trace(fmt.Errorf("token method is invalid"))
 		}
-		return []byte(ja.Secret), nil
+		return key.VerifyKey, nil
 	}
 
 	mapClaims := jwt.MapClaims{}
@@ -0,0 +1,202 @@
+package service
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+
+	"github.com/go-konsultin/errk"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/konsultin/project-goes-here/config"
+	"github.com/konsultin/project-goes-here/dto"
+)
+
+// JwtKey is a key used to sign or verify JWT
+type JwtKey struct {
+	Kid       string
+	Method    jwt.SigningMethod
+	SignKey   interface{} // private key or HMAC secret, nil for verification only keys
+	VerifyKey interface{} // public key or HMAC secret
+}
+
+// JwtKeyring holds the active signing key and every key accepted for verification,
+// so signing keys can be rotated without invalidating tokens issued with previous keys
+type JwtKeyring struct {
+	signing      *JwtKey
+	verification map[string]*JwtKey
+}
+
+// NewJwtKeyring loads JWT keys from config.
+// Tokens are signed with JWT_SIGNING_KEY_FILE (RS256 or EdDSA) when set, otherwise with HS512 JWT_SECRET.
+// JWT_SECRET keeps verifying tokens without kid header unless JWT_VERIFY_LEGACY_SECRET is disabled,
+// and JWT_VERIFICATION_KEYS adds previous public keys
+func NewJwtKeyring(cfg *config.Config) (*JwtKeyring, error) {
+	kr := &JwtKeyring{
+		verification: make(map[string]*JwtKey),
+	}
+
+	// Legacy shared secret, tokens signed by it have no kid header
+	if cfg.JwtSecret != "" {
+		secret := &JwtKey{
+			Kid:       "",
+			Method:    jwt.SigningMethodHS512,
+			SignKey:   []byte(cfg.JwtSecret),
+			VerifyKey: []byte(cfg.JwtSecret),
+		}
+		kr.signing = secret
+		kr.verification[secret.Kid] = secret
+	}
+
+	// Asymmetric signing key
+	if cfg.JwtSigningKeyFile != "" {
+		key, err := loadJwtPrivateKey(cfg.JwtSigningKeyFile, cfg.JwtSigningKeyId)
+		if err != nil {
+			return nil, errk.Trace(err)
+		}
+		kr.signing = key
+
+		// Retire the shared secret once tokens signed by it have expired
+		if !cfg.JwtVerifyLegacySecret {
+			delete(kr.verification, "")
+		}
+		kr.verification[key.Kid] = key
+	}
+
+	// Previous public keys that are still accepted during rotation
+	for kid, path := range cfg.JwtVerificationKeys {
+		key, err := loadJwtPublicKey(path, kid)
+		if err != nil {
+			return nil, errk.Trace(err)
+		}
+		if _, ok := kr.verification[key.Kid]; ok {
+			continue
+		}
+		kr.verification[key.Kid] = key
+	}
+
+	if kr.signing == nil {
+		return nil, fmt.Errorf("JWT_SECRET or JWT_SIGNING_KEY_FILE is required")
+	}
+
+	return kr, nil
+}
+
+// SigningKey returns the key used to issue new tokens
+func (kr *JwtKeyring) SigningKey() *JwtKey {
+	return kr.signing
+}
+
+// VerificationKey returns the key for the given kid
+func (kr *JwtKeyring) VerificationKey(kid string) (*JwtKey, bool) {
+	key, ok := kr.verification[kid]
+	return key, ok
+}
+
+// Jwks returns the public verification keys as JSON Web Key Set, shared secrets are never exposed
+func (kr *JwtKeyring) Jwks() *dto.Jwks {
+	jwks := &dto.Jwks{Keys: []*dto.Jwk{}}
+	for _, key := range kr.verification {
+		jwk := composeJwk(key)
+		if jwk == nil {
+			continue
+		}
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+
+	// Keep output stable
+	sort.Slice(jwks.Keys, func(i, j int) bool {
+		return jwks.Keys[i].Kid < jwks.Keys[j].Kid
+	})
+
+	return jwks
+}
+
+func composeJwk(key *JwtKey) *dto.Jwk {
+	switch pub := key.VerifyKey.(type) {
+	case *rsa.PublicKey:
+		return &dto.Jwk{
+			Kty: "RSA",
+			Kid: key.Kid,
+			Use: "sig",
+			Alg: key.Method.Alg(),
+			N:   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}
+	case ed25519.PublicKey:
+		return &dto.Jwk{
+			Kty: "OKP",
+			Kid: key.Kid,
+			Use: "sig",
+			Alg: key.Method.Alg(),
+			Crv: "Ed25519",
+			X:   base64.RawURLEncoding.EncodeToString(pub),
+		}
+	default:
+		return nil
+	}
+}
+
+func loadJwtPrivateKey(path string, kid string) (*JwtKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read jwt signing key %s: %w", path, err)
+	}
+
+	if rsaKey, err := jwt.ParseRSAPrivateKeyFromPEM(data); err == nil {
+		return newJwtKey(kid, jwt.SigningMethodRS256, rsaKey, &rsaKey.PublicKey)
+	}
+
+	edKey, err := jwt.ParseEdPrivateKeyFromPEM(data)
+	if err != nil {
+		return nil, fmt.Errorf("jwt signing key %s must be a RSA or Ed25519 private key", path)
+	}
+	priv, ok := edKey.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("jwt signing key %s must be a RSA or Ed25519 private key", path)
+	}
+	return newJwtKey(kid, jwt.SigningMethodEdDSA, priv, priv.Public())
+}
+
+func loadJwtPublicKey(path string, kid string) (*JwtKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read jwt verification key %s: %w", path, err)
+	}
+
+	if rsaKey, err := jwt.ParseRSAPublicKeyFromPEM(data); err == nil {
+		return newJwtKey(kid, jwt.SigningMethodRS256, nil, rsaKey)
+	}
+
+	edKey, err := jwt.ParseEdPublicKeyFromPEM(data)
+	if err != nil {
+		return nil, fmt.Errorf("jwt verification key %s must be a RSA or Ed25519 public key", path)
+	}
+	return newJwtKey(kid, jwt.SigningMethodEdDSA, nil, edKey)
+}
+
+func newJwtKey(kid string, method jwt.SigningMethod, signKey interface{}, verifyKey crypto.PublicKey) (*JwtKey, error) {
+	// Derive kid from public key when not configured
+	if kid == "" {
+		der, err := x509.MarshalPKIXPublicKey(verifyKey)
+		if err != nil {
+			return nil, errk.Trace(err)
+		}
+		sum := sha256.Sum256(der)
+		kid = hex.EncodeToString(sum[:8])
+	}
+
+	return &JwtKey{
+		Kid:       kid,
+		Method:    method,
+		SignKey:   signKey,
+		VerifyKey: verifyKey,
+	}, nil
+}
@@ -0,0 +1,179 @@
+package service
+
+import (
+	"time"
+
+	"github.com/go-konsultin/errk"
+	logkOption "github.com/go-konsultin/logk/option"
+	"github.com/konsultin/project-goes-here/dto"
+	"github.com/konsultin/project-goes-here/internal/svc-core/model"
+	"github.com/konsultin/project-goes-here/internal/svc-core/pkg/httpk"
+)
+
+// isLoginThrottled checks if password login of the identifier or from client IP has to be rejected without checking password
+func (s *Service) isLoginThrottled(identifier string) (bool, error) {
+	if s.clientIp != "" {
+		count, err := s.repo.FindLoginFailureByIp(s.clientIp)
+		if err != nil {
+			s.log.Error("Failed to FindLoginFailureByIp", logkOption.Error(err))
+			return false, errk.Trace(err)
+		}
+		if count >= int64(s.config.LoginIpMaxFailedAttempts) {
+			s.log.Warnf("Too many failed logins from IP: %s", s.clientIp)
+			return true, nil
+		}
+	}
+
+	delayed, err := s.repo.ExistsLoginDelay(identifier)
+	if err != nil {
+		s.log.Error("Failed to ExistsLoginDelay", logkOption.Error(err))
+		return false, errk.Trace(err)
+	}
+	if delayed {
+		s.log.Warnf("Login attempt is delayed for identifier: %s", identifier)
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// recordLoginFailure counts a failed password login and delays the next attempt of the identifier progressively.
+// User, if identifier is registered and the user is ACTIVE, is locked once its failures with any of its identifiers
+// reach the threshold. Other statuses are never locked, so the lockout expiring can not activate them
+func (s *Service) recordLoginFailure(identifier string, user *model.User) error {
+	window := time.Duration(s.config.LoginFailureWindow) * time.Second
+
+	if s.clientIp != "" {
+		_, err := s.repo.IncrLoginFailureByIp(s.clientIp, window)
+		if err != nil {
+			s.log.Error("Failed to IncrLoginFailureByIp", logkOption.Error(err))
+			return errk.Trace(err)
+		}
+	}
+
+	count, err := s.repo.IncrLoginFailure(identifier, window)
+	if err != nil {
+		s.log.Error("Failed to IncrLoginFailure", logkOption.Error(err))
+		return errk.Trace(err)
+	}
+
+	if user != nil && user.StatusId == dto.ControlStatus_ACTIVE {
+		userCount, err := s.repo.IncrUserLoginFailure(user.Id, window)
+		if err != nil {
+			s.log.Error("Failed to IncrUserLoginFailure", logkOption.Error(err))
+			return errk.Trace(err)
+		}
+		if userCount >= int64(s.config.LoginMaxFailedAttempts) {
+			return s.lockUser(user, identifier)
+		}
+	}
+
+	// Double the delay on every failure
+	delay := time.Duration(s.config.LoginFailureDelay) * time.Second
+	maxDelay := time.Duration(s.config.LoginMaxFailureDelay) * time.Second
+	for i := int64(1); i < count && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	delay = min(delay, maxDelay)
+	if delay <= 0 {
+		return nil
+	}
+
+	err = s.repo.InsertLoginDelay(identifier, delay)
+	if err != nil {
+		s.log.Error("Failed to InsertLoginDelay", logkOption.Error(err))
+		return errk.Trace(err)
+	}
+
+	return nil
+}
+
+// failLogin records a failed password login and returns the error rejecting it
+func (s *Service) failLogin(identifier string, user *model.User) error {
+	if err := s.recordLoginFailure(identifier, user); err != nil {
+		return errk.Trace(err)
+	}
+	return httpk.UnauthorizedError
+}
+
+// clearLoginFailure resets failed login counters of the identifier and its user after a successful login
+func (s *Service) clearLoginFailure(identifier string, user *model.User) error {
+	err := s.repo.DeleteLoginFailure(identifier)
+	if err != nil {
+		s.log.Error("Failed to DeleteLoginFailure", logkOption.Error(err))
+		return errk.Trace(err)
+	}
+
+	err = s.repo.DeleteUserLoginFailure(user.Id)
+	if err != nil {
+		s.log.Error("Failed to DeleteUserLoginFailure", logkOption.Error(err))
+		return errk.Trace(err)
+	}
+
+	return nil
+}
+
+// lockUser locks user temporarily after too many failed logins
+func (s *Service) lockUser(user *model.User, identifier string) error {
+	now := time.Now()
+	err := s.repo.InsertUserLockout(&model.UserLockout{
+		UserId:   user.Id,
+		LockedAt: now,
+		UnlockAt: now.Add(time.Duration(s.config.LoginLockoutDuration) * time.Second),
+	})
+	if err != nil {
+		s.log.Error("Failed to InsertUserLockout", logkOption.Error(err))
+		return errk.Trace(err)
+	}
+
+	err = s.repo.UpdateUserStatus(user.Id, dto.ControlStatus_LOCKED, s.subject)
+	if err != nil {
+		s.log.Error("Failed to UpdateUserStatus", logkOption.Error(err))
+		return errk.Trace(err)
+	}
+
+	// Counter starts over once unlocked
+	err = s.clearLoginFailure(identifier, user)
+	if err != nil {
+		return errk.Trace(err)
+	}
+
+	user.StatusId = dto.ControlStatus_LOCKED
+	s.log.Warnf("User locked after too many failed logins. UserId=%d", user.Id)
+
+	return nil
+}
+
+// expireUserLockout activates a LOCKED user whose lockout after failed logins has passed. Every login calls it before
+// checking status of the user, user stays LOCKED while its lockout lasts or if it was not locked by failed logins
+func (s *Service) expireUserLockout(user *model.User) error {
+	if user.StatusId != dto.ControlStatus_LOCKED {
+		return nil
+	}
+
+	lockout, err := s.repo.FindUserLockout(user.Id)
+	if err != nil {
+		s.log.Error("Failed to FindUserLockout", logkOption.Error(err))
+		return errk.Trace(err)
+	}
+	if lockout == nil || time.Now().Before(lockout.UnlockAt) {
+		return nil
+	}
+
+	err = s.repo.UpdateUserStatus(user.Id, dto.ControlStatus_ACTIVE, s.subject)
+	if err != nil {
+		s.log.Error("Failed to UpdateUserStatus", logkOption.Error(err))
+		return errk.Trace(err)
+	}
+
+	err = s.repo.DeleteUserLockout(user.Id)
+	if err != nil {
+		s.log.Error("Failed to DeleteUserLockout", logkOption.Error(err))
+		return errk.Trace(err)
+	}
+
+	user.StatusId = dto.ControlStatus_ACTIVE
+	s.log.Infof("User unlocked after lockout has passed. UserId=%d", user.Id)
+
+	return nil
+}
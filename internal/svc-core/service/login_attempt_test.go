@@ -0,0 +1,142 @@
+package service
+
+import (
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-konsultin/logk"
+	"github.com/konsultin/project-goes-here/config"
+	"github.com/konsultin/project-goes-here/dto"
+	"github.com/konsultin/project-goes-here/internal/svc-core/constant"
+	"github.com/konsultin/project-goes-here/internal/svc-core/model"
+	"github.com/konsultin/project-goes-here/internal/svc-core/repository"
+	"github.com/konsultin/project-goes-here/pkg/redis"
+)
+
+// newRedisTestService creates a service whose repository only has redis, backed by an in-memory server.
+// Only paths that do not touch the database can be tested with it
+func newRedisTestService(t *testing.T, cfg *config.Config) (*Service, *miniredis.Miniredis) {
+	t.Helper()
+
+	mr := miniredis.RunT(t)
+	port, err := strconv.Atoi(mr.Port())
+	if err != nil {
+		t.Fatalf("parse miniredis port: %v", err)
+	}
+
+	client, err := redis.New(redis.Config{Host: mr.Host(), Port: port})
+	if err != nil {
+		t.Fatalf("connect to miniredis: %v", err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+
+	repo := (&repository.Repository{}).WithRedis(client)
+
+	return NewService(repo, cfg).WithLog(logk.Get()), mr
+}
+
+func newLoginAttemptConfig() *config.Config {
+	return &config.Config{
+		LoginMaxFailedAttempts:   3,
+		LoginIpMaxFailedAttempts: 100,
+		LoginFailureWindow:       900,
+		LoginFailureDelay:        0,
+		LoginMaxFailureDelay:     0,
+		LoginLockoutDuration:     60,
+	}
+}
+
+func TestFailLoginDoesNotLockInactiveUser(t *testing.T) {
+	tests := []struct {
+		name   string
+		status dto.ControlStatus_Enum
+	}{
+		{name: "inactive", status: dto.ControlStatus_INACTIVE},
+		{name: "pending", status: dto.ControlStatus_PENDING},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := newLoginAttemptConfig()
+			svc, mr := newRedisTestService(t, cfg)
+			user := &model.User{Id: 7, StatusId: tt.status}
+
+			for i := 0; i < cfg.LoginMaxFailedAttempts*2; i++ {
+				if err := svc.failLogin("jane@example.com", user); err == nil {
+					t.Fatalf("failLogin() error = nil, want UnauthorizedError")
+				}
+			}
+
+			if user.StatusId != tt.status {
+				t.Fatalf("status after failed logins = %d, want %d", user.StatusId, tt.status)
+			}
+			lockout, err := svc.repo.FindUserLockout(user.Id)
+			if err != nil {
+				t.Fatalf("FindUserLockout() error = %v", err)
+			}
+			if lockout != nil {
+				t.Fatalf("FindUserLockout() = %+v, want no lockout", lockout)
+			}
+
+			// Once a lockout would have expired, the user still has its own status
+			mr.FastForward(time.Duration(cfg.LoginLockoutDuration+1) * time.Second)
+			if err = svc.expireUserLockout(user); err != nil {
+				t.Fatalf("expireUserLockout() error = %v", err)
+			}
+			if user.StatusId != tt.status {
+				t.Errorf("status after lockout expiry = %d, want %d", user.StatusId, tt.status)
+			}
+		})
+	}
+}
+
+func TestFailLoginCountsPerUser(t *testing.T) {
+	cfg := newLoginAttemptConfig()
+	svc, mr := newRedisTestService(t, cfg)
+	user := &model.User{Id: 7, StatusId: dto.ControlStatus_ACTIVE}
+	userKey := fmt.Sprintf("%s%d", constant.RedisUserLoginFailurePrefix, user.Id)
+
+	// Failures with every identifier of the user add up to the same counter
+	identifiers := []string{"jane@example.com", "+6281234567890"}
+	for _, identifier := range identifiers {
+		_ = svc.failLogin(identifier, user)
+	}
+
+	got, err := mr.Get(userKey)
+	if err != nil {
+		t.Fatalf("user failure counter: %v", err)
+	}
+	if got != strconv.Itoa(len(identifiers)) {
+		t.Errorf("user failure counter = %s, want %d", got, len(identifiers))
+	}
+	if user.StatusId != dto.ControlStatus_ACTIVE {
+		t.Errorf("status below threshold = %d, want ACTIVE", user.StatusId)
+	}
+
+	if err = svc.clearLoginFailure("jane@example.com", user); err != nil {
+		t.Fatalf("clearLoginFailure() error = %v", err)
+	}
+	if mr.Exists(userKey) {
+		t.Errorf("user failure counter still exists after clearLoginFailure()")
+	}
+}
+
+func TestFailLoginWithoutUser(t *testing.T) {
+	cfg := newLoginAttemptConfig()
+	svc, mr := newRedisTestService(t, cfg)
+
+	for i := 0; i < cfg.LoginMaxFailedAttempts*2; i++ {
+		_ = svc.failLogin("nobody@example.com", nil)
+	}
+
+	got, err := mr.Get(constant.RedisLoginFailurePrefix + "nobody@example.com")
+	if err != nil {
+		t.Fatalf("identifier failure counter: %v", err)
+	}
+	if got != strconv.Itoa(cfg.LoginMaxFailedAttempts*2) {
+		t.Errorf("identifier failure counter = %s, want %d", got, cfg.LoginMaxFailedAttempts*2)
+	}
+}
@@ -0,0 +1,193 @@
+package service
+
+import (
+	"strings"
+	"time"
+
+	"github.com/go-konsultin/errk"
+	logkOption "github.com/go-konsultin/logk/option"
+	"github.com/konsultin/project-goes-here/dto"
+	"github.com/konsultin/project-goes-here/internal/svc-core/model"
+)
+
+// OAuth token types, RFC 7009 section 2.1
+const (
+	OAuthTokenTypeAccessToken  = "access_token"
+	OAuthTokenTypeRefreshToken = "refresh_token"
+)
+
+// IntrospectOAuthToken returns whether a token is active and its claims, so other services can check our tokens
+// without sharing the signing secret. Only SERVICE clients can introspect tokens
+func (s *Service) IntrospectOAuthToken(credentials *OAuthClientCredentials, payload *dto.OAuthTokenIntrospection_Payload) (*dto.OAuthTokenIntrospection_Result, error) {
+	clientAuth, err := s.authenticateOAuthClient(credentials)
+	if err != nil {
+		return nil, err
+	}
+
+	if clientAuth.ClientTypeId != dto.Role_SERVICE {
+		s.log.Warnf("Client is not allowed to introspect tokens. ClientId=%s ClientTypeId=%d",
+			clientAuth.ClientId, clientAuth.ClientTypeId)
+		return nil, newOAuthError(OAuthErrorUnauthorizedClient, "client is not allowed to introspect tokens")
+	}
+
+	if payload.Token == "" {
+		return nil, newOAuthError(OAuthErrorInvalidRequest, "token is required")
+	}
+
+	inactive := &dto.OAuthTokenIntrospection_Result{Active: false}
+
+	// Invalid, expired or foreign token is reported as inactive, RFC 7662 section 2.2
+	jwtAdapter := s.NewJwtAdapter()
+	claims, err := jwtAdapter.ValidateWithoutAudience(payload.Token)
+	if err != nil {
+		s.log.Debugf("Introspected token is not valid. ClientId=%s Error=%v", clientAuth.ClientId, err)
+		return inactive, nil
+	}
+
+	active, err := s.isTokenActive(claims)
+	if err != nil {
+		return nil, errk.Trace(err)
+	}
+	if !active {
+		return inactive, nil
+	}
+
+	tokenType := OAuthTokenTypeAccessToken
+	if isRefreshToken(claims) {
+		tokenType = OAuthTokenTypeRefreshToken
+	}
+
+	var act *dto.OAuthTokenActor
+	if claims.Act != nil {
+		act = &dto.OAuthTokenActor{Sub: claims.Act.Sub}
+	}
+
+	return &dto.OAuthTokenIntrospection_Result{
+		Active:    true,
+		Sub:       claims.Sub,
+		Scope:     strings.Join(claims.Aud, " "),
+		Exp:       claims.Exp,
+		Iat:       claims.Iat,
+		Iss:       claims.Iss,
+		Jti:       claims.Jti,
+		Ent:       claims.Ent,
+		TokenType: tokenType,
+		Act:       act,
+	}, nil
+}
+
+// RevokeOAuthToken revokes an access or refresh token. User and admin tokens revoke their backing session, so both
+// tokens of the session stop working. Tokens of anonymous and service clients are not backed by a session, they are
+// rejected until they expire. A token can only be revoked by the client it was issued to, a token of another client is
+// left as is and the request still succeeds, RFC 7009 section 2.1
+func (s *Service) RevokeOAuthToken(credentials *OAuthClientCredentials, payload *dto.OAuthTokenRevocation_Payload) error {
+	clientAuth, err := s.authenticateOAuthClient(credentials)
+	if err != nil {
+		return err
+	}
+
+	if payload.Token == "" {
+		return newOAuthError(OAuthErrorInvalidRequest, "token is required")
+	}
+
+	// Invalid or expired token does not need to be revoked, RFC 7009 section 2.2
+	jwtAdapter := s.NewJwtAdapter()
+	claims, err := jwtAdapter.ValidateWithoutAudience(payload.Token)
+	if err != nil {
+		s.log.Debugf("Revoked token is not valid. ClientId=%s Error=%v", clientAuth.ClientId, err)
+		return nil
+	}
+
+	switch dto.Role_Enum(claims.Ent) {
+	case dto.Role_USER, dto.Role_ADMIN:
+		if claims.ClientId != clientAuth.ClientId {
+			s.log.Warnf("Client revoking token of another client. ClientId=%s TokenClientId=%s", clientAuth.ClientId, claims.ClientId)
+			return nil
+		}
+
+		session, err := s.repo.FindSessionByXid(claims.Jti)
+		if err != nil {
+			s.log.Error("Failed to FindSessionByXid", logkOption.Error(err))
+			return errk.Trace(err)
+		}
+		if session == nil || session.SubjectId != claims.Sub {
+			return nil
+		}
+
+		// The client revoking the token ends the impersonation
+		err = s.WithSubject(&model.Subject{
+			Id:       clientAuth.ClientId,
+			Role:     dto.Role_Enum_name[int32(clientAuth.ClientTypeId)],
+			FullName: clientAuth.Name,
+		}).auditImpersonationEnd(session, impersonationEndRevokedByClient)
+		if err != nil {
+			return err
+		}
+
+		err = s.repo.DeleteSessionByXid(session.Xid)
+		if err != nil {
+			s.log.Error("Failed to DeleteSessionByXid", logkOption.Error(err))
+			return errk.Trace(err)
+		}
+	case dto.Role_ANONYMOUS_USER, dto.Role_ANONYMOUS_ADMIN, dto.Role_SERVICE:
+		if claims.Sub != clientAuth.ClientId {
+			s.log.Warnf("Client revoking token of another client. ClientId=%s TokenClientId=%s", clientAuth.ClientId, claims.Sub)
+			return nil
+		}
+
+		lifetime := time.Until(time.Unix(claims.Exp, 0))
+		if lifetime <= 0 {
+			return nil
+		}
+
+		err = s.repo.InsertRevokedToken(claims.Sub, claims.Jti, lifetime)
+		if err != nil {
+			s.log.Error("Failed to InsertRevokedToken", logkOption.Error(err))
+			return errk.Trace(err)
+		}
+	default:
+		return nil
+	}
+
+	s.log.Infof("OAuth token revoked. ClientId=%s SubjectId=%s SessionXid=%s", clientAuth.ClientId, claims.Sub, claims.Jti)
+
+	return nil
+}
+
+// isTokenActive checks if a valid token has not been revoked. Unlike bearer token authentication, the session is only
+// read, so introspection has no side effect
+func (s *Service) isTokenActive(claims *JwtResponse) (bool, error) {
+	switch dto.Role_Enum(claims.Ent) {
+	case dto.Role_USER, dto.Role_ADMIN:
+		session, err := s.repo.FindSessionByXid(claims.Jti)
+		if err != nil {
+			s.log.Error("Failed to FindSessionByXid", logkOption.Error(err))
+			return false, errk.Trace(err)
+		}
+		return session != nil && session.SubjectId == claims.Sub && session.StatusId == dto.ControlStatus_ACTIVE, nil
+	case dto.Role_ANONYMOUS_USER, dto.Role_ANONYMOUS_ADMIN, dto.Role_SERVICE:
+		return s.isClientTokenActive(claims)
+	default:
+		return false, nil
+	}
+}
+
+// isClientTokenActive checks that a token not backed by a session has not been revoked, and its client is active
+func (s *Service) isClientTokenActive(claims *JwtResponse) (bool, error) {
+	inactive, err := s.repo.ExistsInactiveClient(claims.Sub)
+	if err != nil {
+		s.log.Error("Failed to ExistsInactiveClient", logkOption.Error(err))
+		return false, errk.Trace(err)
+	}
+	if inactive {
+		return false, nil
+	}
+
+	revoked, err := s.repo.ExistsRevokedToken(claims.Sub, claims.Jti)
+	if err != nil {
+		s.log.Error("Failed to ExistsRevokedToken", logkOption.Error(err))
+		return false, errk.Trace(err)
+	}
+
+	return !revoked, nil
+}
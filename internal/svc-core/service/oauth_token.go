@@ -0,0 +1,145 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/go-konsultin/errk"
+	logkOption "github.com/go-konsultin/logk/option"
+	"github.com/konsultin/project-goes-here/dto"
+	"github.com/konsultin/project-goes-here/internal/svc-core/model"
+	"github.com/konsultin/project-goes-here/internal/svc-core/pkg/valk"
+)
+
+// OAuth 2.0 error codes, RFC 6749 section 5.2
+const (
+	OAuthErrorInvalidRequest       = "invalid_request"
+	OAuthErrorInvalidClient        = "invalid_client"
+	OAuthErrorUnauthorizedClient   = "unauthorized_client"
+	OAuthErrorUnsupportedGrantType = "unsupported_grant_type"
+	OAuthErrorInvalidScope         = "invalid_scope"
+	OAuthErrorServerError          = "server_error"
+)
+
+const OAuthGrantTypeClientCredentials = "client_credentials"
+
+// OAuthError is an error of OAuth endpoints, rendered as RFC 6749 error response instead of the common response
+type OAuthError struct {
+	Code        string
+	Description string
+}
+
+func (e *OAuthError) Error() string {
+	return e.Code + ": " + e.Description
+}
+
+func newOAuthError(code string, description string) *OAuthError {
+	return &OAuthError{Code: code, Description: description}
+}
+
+// OAuthClientCredentials is the client authentication of an OAuth request, from Basic auth or form fields
+type OAuthClientCredentials struct {
+	ClientId     string
+	ClientSecret string
+}
+
+// IssueOAuthToken issues an access token for the grant. Only client_credentials grant is supported, the token is
+// granted with privileges of the SERVICE role and lives as long as the token lifetime of the client
+func (s *Service) IssueOAuthToken(credentials *OAuthClientCredentials, payload *dto.OAuthToken_Payload) (*dto.OAuthToken_Result, error) {
+	switch payload.GrantType {
+	case OAuthGrantTypeClientCredentials:
+	case "":
+		return nil, newOAuthError(OAuthErrorInvalidRequest, "grant_type is required")
+	default:
+		s.log.Warnf("Unsupported OAuth grant type. GrantType=%s", payload.GrantType)
+		return nil, newOAuthError(OAuthErrorUnsupportedGrantType, "grant_type is not supported")
+	}
+
+	clientAuth, err := s.authenticateOAuthClient(credentials)
+	if err != nil {
+		return nil, err
+	}
+
+	if clientAuth.ClientTypeId != dto.Role_SERVICE {
+		s.log.Warnf("Client is not allowed to use client_credentials grant. ClientId=%s ClientTypeId=%d",
+			clientAuth.ClientId, clientAuth.ClientTypeId)
+		return nil, newOAuthError(OAuthErrorUnauthorizedClient, "client is not allowed to use this grant type")
+	}
+
+	rolePrivileges, err := s.repo.FindRolePrivilegeByRoleId(int32(dto.Role_SERVICE))
+	if err != nil {
+		s.log.Error("Failed to FindRolePrivilegeByRoleId", logkOption.Error(err))
+		return nil, errk.Trace(err)
+	}
+
+	granted := make([]string, 0, len(rolePrivileges))
+	for _, val := range rolePrivileges {
+		granted = append(granted, val.Privilege.Xid)
+	}
+
+	// Requested scope narrows down the privileges, it can not exceed them
+	audience := granted
+	if payload.Scope != "" {
+		audience = strings.Fields(payload.Scope)
+		for _, scope := range audience {
+			if !valk.InArrayString(scope, granted) {
+				s.log.Warnf("Client requested scope that is not granted. ClientId=%s Scope=%s", clientAuth.ClientId, scope)
+				return nil, newOAuthError(OAuthErrorInvalidScope, "requested scope is not granted")
+			}
+		}
+	}
+
+	jwtAdapter := s.NewJwtAdapter()
+	session, err := jwtAdapter.Issue(IssueJwtPayload{
+		Subject:     clientAuth.ClientId,
+		Audience:    audience,
+		Lifetime:    clientAuth.Options.TokenLifetime,
+		SessionId:   s.generateXid(),
+		SubjectType: int32(dto.Role_SERVICE),
+	})
+	if err != nil {
+		s.log.Error("Failed to issue jwt payload", logkOption.Error(err))
+		return nil, errk.Trace(err)
+	}
+
+	s.log.Infof("OAuth access token issued. ClientId=%s GrantType=%s", clientAuth.ClientId, payload.GrantType)
+
+	return &dto.OAuthToken_Result{
+		AccessToken: session.Token,
+		TokenType:   "Bearer",
+		ExpiresIn:   session.ExpiredAt - time.Now().Unix(),
+		Scope:       strings.Join(audience, " "),
+	}, nil
+}
+
+// authenticateOAuthClient verifies client credentials of an OAuth request. Unknown client, invalid secret and
+// inactive client are not told apart
+func (s *Service) authenticateOAuthClient(credentials *OAuthClientCredentials) (*model.ClientAuth, error) {
+	if credentials == nil || credentials.ClientId == "" {
+		return nil, newOAuthError(OAuthErrorInvalidClient, "client authentication is required")
+	}
+
+	clientAuth, err := s.repo.FindClientAuthByClientId(credentials.ClientId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			s.log.Warnf("OAuth client is not found. ClientId=%s", credentials.ClientId)
+			return nil, newOAuthError(OAuthErrorInvalidClient, "client authentication failed")
+		}
+		s.log.Error("Failed to FindClientAuthByClientId", logkOption.Error(err))
+		return nil, errk.Trace(err)
+	}
+
+	if !s.verifyClientSecret(clientAuth, credentials.ClientSecret) {
+		s.log.Warnf("Invalid OAuth client secret. ClientId=%s", clientAuth.ClientId)
+		return nil, newOAuthError(OAuthErrorInvalidClient, "client authentication failed")
+	}
+
+	if clientAuth.StatusId != dto.ControlStatus_ACTIVE {
+		s.log.Warnf("OAuth client is not active. ClientId=%s StatusId=%d", clientAuth.ClientId, clientAuth.StatusId)
+		return nil, newOAuthError(OAuthErrorInvalidClient, "client authentication failed")
+	}
+
+	return clientAuth, nil
+}
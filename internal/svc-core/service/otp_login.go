@@ -0,0 +1,289 @@
+package service
+
+import (
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-konsultin/errk"
+	logkOption "github.com/go-konsultin/logk/option"
+	"github.com/konsultin/project-goes-here/dto"
+	specErr "github.com/konsultin/project-goes-here/internal/errors"
+	"github.com/konsultin/project-goes-here/internal/svc-core/model"
+	"github.com/konsultin/project-goes-here/internal/svc-core/pkg/httpk"
+	"github.com/konsultin/project-goes-here/internal/svc-core/pkg/sender"
+)
+
+const otpLoginCodeDigits = 6
+
+// RequestLoginOtp sends a one-time login code to an email or phone of a user.
+// Requests are limited per identifier whether it is registered or not, so it can not be used to discover accounts
+// Requires anonymous session bearer token for authentication
+func (s *Service) RequestLoginOtp(payload *dto.RequestLoginOtp_Payload) (*dto.RequestLoginOtp_Result, error) {
+	// Verify anonymous session token first
+	if err := s.verifyAnonymousSession(); err != nil {
+		return nil, err
+	}
+
+	identifier := normalizeIdentifier(payload.Identifier)
+
+	// Limit requests before looking up user
+	now := time.Now()
+	interval := time.Duration(s.config.OtpLoginResendInterval) * time.Second
+	allowed, err := s.repo.InsertLoginOtpCooldown(identifier, interval)
+	if err != nil {
+		s.log.Error("Failed to InsertLoginOtpCooldown", logkOption.Error(err))
+		return nil, errk.Trace(err)
+	}
+	if !allowed {
+		s.log.Warnf("Login code requested again too soon for identifier: %s", identifier)
+		return nil, specErr.TooManyOtpRequests
+	}
+
+	count, err := s.repo.IncrLoginOtpRequest(identifier, time.Duration(s.config.OtpLoginRequestWindow)*time.Second)
+	if err != nil {
+		s.log.Error("Failed to IncrLoginOtpRequest", logkOption.Error(err))
+		return nil, errk.Trace(err)
+	}
+	if count > int64(s.config.OtpLoginMaxRequests) {
+		s.log.Warnf("Too many login code requests for identifier: %s", identifier)
+		return nil, specErr.TooManyOtpRequests
+	}
+
+	lifetime := time.Duration(s.config.OtpLoginLifetime) * time.Second
+	result := &dto.RequestLoginOtp_Result{
+		ExpiredAt: now.Add(lifetime).Unix(),
+		ResendAt:  now.Add(interval).Unix(),
+	}
+
+	// Find user by identifier
+	user, err := s.repo.FindUserByIdentifier(identifier)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			s.log.Warnf("Login code requested for unknown identifier: %s", identifier)
+			return result, nil
+		}
+		s.log.Error("Failed to find user", logkOption.Error(err))
+		return nil, errk.Trace(err)
+	}
+
+	if err = s.expireUserLockout(user); err != nil {
+		return nil, errk.Trace(err)
+	}
+	if user.StatusId != dto.ControlStatus_ACTIVE {
+		s.log.Warnf("Login code requested for inactive user. UserId=%d Status=%d", user.Id, user.StatusId)
+		return result, nil
+	}
+
+	// Username can not receive a code
+	if identifier != user.Email.String && identifier != user.Phone.String {
+		s.log.Warnf("Login code requested for identifier that is not an email or phone. UserId=%d", user.Id)
+		return result, nil
+	}
+
+	// Issue code, replacing the previous one
+	code, err := generateNumericCode(otpLoginCodeDigits)
+	if err != nil {
+		return nil, errk.Trace(err)
+	}
+
+	err = s.repo.InsertLoginOtp(&model.LoginOtp{
+		UserId:     user.Id,
+		Identifier: identifier,
+		CodeHash:   hashSecretToken(identifier + ":" + code),
+		CreatedAt:  now,
+	}, lifetime)
+	if err != nil {
+		s.log.Error("Failed to InsertLoginOtp", logkOption.Error(err))
+		return nil, errk.Trace(err)
+	}
+
+	// Invalid attempts of the previous code are carried over, so resending does not reset them
+	err = s.repo.ExpireLoginOtpAttempt(identifier, lifetime)
+	if err != nil {
+		s.log.Error("Failed to ExpireLoginOtpAttempt", logkOption.Error(err))
+		return nil, errk.Trace(err)
+	}
+
+	channel := sender.ChannelOf(identifier)
+	err = s.sender.Send(s.ctx, &sender.Message{
+		Channel:   channel,
+		Recipient: identifier,
+		Subject:   "Your login code",
+		Body:      s.composeLoginOtpBody(channel, identifier, code, lifetime),
+	})
+	if err != nil {
+		s.log.Error("Failed to send login code", logkOption.Error(err))
+		return nil, errk.Trace(err)
+	}
+
+	s.log.Infof("Login code sent. UserId=%d", user.Id)
+
+	return result, nil
+}
+
+// LoginWithOtp creates user session with a one-time login code sent to email or phone.
+// Code is dropped after too many invalid attempts. User with two-factor authentication enabled gets a challenge
+// token to exchange for the session instead
+// Requires anonymous session bearer token for authentication
+func (s *Service) LoginWithOtp(payload *dto.LoginOtp_Payload) (*dto.CreateUserSession_Result_Data, error) {
+	// Verify anonymous session token first
+	if err := s.verifyAnonymousSession(); err != nil {
+		return nil, err
+	}
+
+	identifier := normalizeIdentifier(payload.Identifier)
+
+	otp, err := s.repo.FindLoginOtp(identifier)
+	if err != nil {
+		s.log.Error("Failed to FindLoginOtp", logkOption.Error(err))
+		return nil, errk.Trace(err)
+	}
+	if otp == nil {
+		s.log.Warnf("No pending login code for identifier: %s", identifier)
+		return nil, specErr.InvalidOtpCode
+	}
+
+	// Count attempt before comparing, so concurrent attempts can not go over the limit
+	attempts, err := s.repo.IncrLoginOtpAttempt(identifier, time.Duration(s.config.OtpLoginLifetime)*time.Second)
+	if err != nil {
+		s.log.Error("Failed to IncrLoginOtpAttempt", logkOption.Error(err))
+		return nil, errk.Trace(err)
+	}
+	if attempts > int64(s.config.OtpLoginMaxAttempts) {
+		s.log.Warnf("Too many login code attempts for identifier: %s", identifier)
+		if err = s.discardLoginOtp(identifier); err != nil {
+			return nil, errk.Trace(err)
+		}
+		return nil, specErr.InvalidOtpCode
+	}
+
+	codeHash := hashSecretToken(identifier + ":" + payload.Code)
+	if subtle.ConstantTimeCompare([]byte(codeHash), []byte(otp.CodeHash)) != 1 {
+		// Code is dropped with the last allowed attempt
+		if attempts == int64(s.config.OtpLoginMaxAttempts) {
+			s.log.Warnf("Too many invalid login codes for identifier: %s", identifier)
+			if err = s.discardLoginOtp(identifier); err != nil {
+				return nil, errk.Trace(err)
+			}
+		}
+		return nil, specErr.InvalidOtpCode
+	}
+
+	// Code is single use
+	consumed, err := s.repo.ConsumeLoginOtp(identifier)
+	if err != nil {
+		s.log.Error("Failed to ConsumeLoginOtp", logkOption.Error(err))
+		return nil, errk.Trace(err)
+	}
+	if !consumed {
+		s.log.Warnf("Login code has been consumed for identifier: %s", identifier)
+		return nil, specErr.InvalidOtpCode
+	}
+
+	err = s.repo.DeleteLoginOtpAttempt(identifier)
+	if err != nil {
+		s.log.Error("Failed to DeleteLoginOtpAttempt", logkOption.Error(err))
+		return nil, errk.Trace(err)
+	}
+
+	user, err := s.getUserById(otp.UserId)
+	if err != nil {
+		return nil, errk.Trace(err)
+	}
+
+	// Identifier may have been changed since the code was sent
+	if identifier != user.Email.String && identifier != user.Phone.String {
+		s.log.Warnf("Identifier no longer belongs to user. UserId=%d", user.Id)
+		return nil, specErr.InvalidOtpCode
+	}
+
+	if err = s.expireUserLockout(user); err != nil {
+		return nil, errk.Trace(err)
+	}
+	if user.StatusId != dto.ControlStatus_ACTIVE {
+		s.log.Warnf("User account is not active. UserId=%d Status=%d", user.Id, user.StatusId)
+		return nil, httpk.ForbiddenError
+	}
+
+	// Receiving the code proves the identifier, mark its password credential as verified
+	if err = s.markIdentifierVerified(identifier); err != nil {
+		return nil, errk.Trace(err)
+	}
+
+	// Complete login with a second factor if enabled
+	twoFactor, err := s.isTwoFactorEnabled(user)
+	if err != nil {
+		return nil, errk.Trace(err)
+	}
+	if twoFactor {
+		return s.createTwoFactorChallenge(user, dto.AuthProvider_OTP, identifier, payload.Device)
+	}
+
+	return s.CreateUserSession(user, dto.AuthProvider_OTP, payload.Device, time.Now())
+}
+
+// discardLoginOtp drops the login code of an identifier with its attempts, a new code has to be requested
+func (s *Service) discardLoginOtp(identifier string) error {
+	_, err := s.repo.ConsumeLoginOtp(identifier)
+	if err != nil {
+		s.log.Error("Failed to ConsumeLoginOtp", logkOption.Error(err))
+		return errk.Trace(err)
+	}
+
+	err = s.repo.DeleteLoginOtpAttempt(identifier)
+	if err != nil {
+		s.log.Error("Failed to DeleteLoginOtpAttempt", logkOption.Error(err))
+		return errk.Trace(err)
+	}
+
+	return nil
+}
+
+// markIdentifierVerified marks PASSWORD credential of the identifier as verified, if it exists
+func (s *Service) markIdentifierVerified(identifier string) error {
+	credential, err := s.repo.FindCredentialByKey(dto.AuthProvider_PASSWORD, identifier)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		s.log.Error("Failed to find credential", logkOption.Error(err))
+		return errk.Trace(err)
+	}
+	if credential.IsVerified {
+		return nil
+	}
+
+	err = s.repo.UpdateCredentialVerified(credential.Id)
+	if err != nil {
+		s.log.Error("Failed to UpdateCredentialVerified", logkOption.Error(err))
+		return errk.Trace(err)
+	}
+
+	return nil
+}
+
+// composeLoginOtpBody creates the message containing the login code, and a magic link for email if login URL is configured
+func (s *Service) composeLoginOtpBody(channel sender.Channel, identifier, code string, lifetime time.Duration) string {
+	body := fmt.Sprintf("Your login code is %s. It expires in %s.", code, lifetime)
+	if channel != sender.ChannelEmail || s.config.OtpLoginUrl == "" {
+		return body
+	}
+
+	q := url.Values{}
+	q.Set("identifier", identifier)
+	q.Set("code", code)
+
+	link := s.config.OtpLoginUrl
+	if strings.Contains(link, "?") {
+		link += "&" + q.Encode()
+	} else {
+		link += "?" + q.Encode()
+	}
+
+	return fmt.Sprintf("%s Or open this link to login: %s", body, link)
+}
@@ -0,0 +1,395 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-konsultin/errk"
+	logkOption "github.com/go-konsultin/logk/option"
+	"github.com/konsultin/project-goes-here/dto"
+	specErr "github.com/konsultin/project-goes-here/internal/errors"
+	"github.com/konsultin/project-goes-here/internal/svc-core/model"
+	"github.com/konsultin/project-goes-here/internal/svc-core/pkg/httpk"
+	"github.com/konsultin/project-goes-here/internal/svc-core/pkg/pwpolicy"
+	"github.com/konsultin/project-goes-here/internal/svc-core/pkg/sender"
+)
+
+// RequestPasswordReset sends a single-use password reset token to the user of the identifier.
+// It succeeds even if identifier is not registered, so it can not be used to discover accounts
+// Requires anonymous session bearer token for authentication
+func (s *Service) RequestPasswordReset(payload *dto.RequestPasswordReset_Payload) error {
+	// Verify anonymous session token first
+	if err := s.verifyAnonymousSession(); err != nil {
+		return err
+	}
+
+	// Normalize identifier (lowercase for email)
+	identifier := strings.TrimSpace(payload.Identifier)
+	if strings.Contains(identifier, "@") {
+		identifier = strings.ToLower(identifier)
+	}
+
+	// Find user by identifier
+	user, err := s.repo.FindUserByIdentifier(identifier)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			s.log.Warnf("Password reset requested for unknown identifier: %s", identifier)
+			return nil
+		}
+		s.log.Error("Failed to find user", logkOption.Error(err))
+		return errk.Trace(err)
+	}
+
+	if user.StatusId != dto.ControlStatus_ACTIVE {
+		s.log.Warnf("Password reset requested for inactive user. UserId=%d Status=%d", user.Id, user.StatusId)
+		return nil
+	}
+
+	// Only users signing in with password can reset it
+	credentials, err := s.findPasswordCredentials(user.Id)
+	if err != nil {
+		return errk.Trace(err)
+	}
+	if len(credentials) == 0 {
+		s.log.Warnf("Password reset requested for user without password. UserId=%d", user.Id)
+		return nil
+	}
+
+	// Deliver to the identifier if it is reachable, otherwise to email or phone of user
+	recipient := identifier
+	if identifier != user.Email.String && identifier != user.Phone.String {
+		recipient = user.Email.String
+		if recipient == "" {
+			recipient = user.Phone.String
+		}
+	}
+	if recipient == "" {
+		s.log.Warnf("Password reset requested for user without email or phone. UserId=%d", user.Id)
+		return nil
+	}
+
+	// Issue token
+	token, err := generateSecretToken()
+	if err != nil {
+		return errk.Trace(err)
+	}
+
+	lifetime := time.Duration(s.config.PasswordResetTokenLifetime) * time.Second
+	err = s.repo.InsertPasswordResetToken(hashSecretToken(token), &model.PasswordResetToken{
+		UserId:    user.Id,
+		UserXid:   user.Xid,
+		Recipient: recipient,
+		CreatedAt: time.Now(),
+	}, lifetime)
+	if err != nil {
+		s.log.Error("Failed to InsertPasswordResetToken", logkOption.Error(err))
+		return errk.Trace(err)
+	}
+
+	// Send token
+	err = s.sender.Send(s.ctx, &sender.Message{
+		Channel:   sender.ChannelOf(recipient),
+		Recipient: recipient,
+		Subject:   "Reset your password",
+		Body:      s.composePasswordResetBody(token, lifetime),
+	})
+	if err != nil {
+		s.log.Error("Failed to send password reset token", logkOption.Error(err))
+		return errk.Trace(err)
+	}
+
+	s.log.Infof("Password reset token sent. UserId=%d", user.Id)
+
+	return nil
+}
+
+// ConfirmPasswordReset sets a new password with a password reset token, then revokes every session of the user
+// Requires anonymous session bearer token for authentication
+func (s *Service) ConfirmPasswordReset(payload *dto.ConfirmPasswordReset_Payload) error {
+	// Verify anonymous session token first
+	if err := s.verifyAnonymousSession(); err != nil {
+		return err
+	}
+
+	tokenHash := hashSecretToken(payload.Token)
+	reset, err := s.repo.FindPasswordResetToken(tokenHash)
+	if err != nil {
+		s.log.Error("Failed to FindPasswordResetToken", logkOption.Error(err))
+		return errk.Trace(err)
+	}
+	if reset == nil {
+		s.log.Warn("Password reset token is invalid or has expired")
+		return specErr.InvalidPasswordResetToken
+	}
+
+	user, err := s.getUserById(reset.UserId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return specErr.InvalidPasswordResetToken
+		}
+		return errk.Trace(err)
+	}
+
+	// Check policy before consuming the token, so the user can retry with another password
+	if err = s.validatePassword("newPassword", payload.NewPassword, user); err != nil {
+		return err
+	}
+
+	// Token is deleted on first use
+	reset, err = s.repo.ConsumePasswordResetToken(tokenHash)
+	if err != nil {
+		s.log.Error("Failed to ConsumePasswordResetToken", logkOption.Error(err))
+		return errk.Trace(err)
+	}
+	if reset == nil {
+		s.log.Warn("Password reset token has been used concurrently")
+		return specErr.InvalidPasswordResetToken
+	}
+
+	// Hash password
+	hash, err := s.hashPassword(payload.NewPassword)
+	if err != nil {
+		return errk.Trace(err)
+	}
+
+	// Every identifier of the user shares the same password
+	credentials, err := s.findPasswordCredentials(user.Id)
+	if err != nil {
+		return errk.Trace(err)
+	}
+	for _, credential := range credentials {
+		err = s.repo.UpdateCredentialSecret(credential.Id, hash)
+		if err != nil {
+			s.log.Error("Failed to UpdateCredentialSecret", logkOption.Error(err))
+			return errk.Trace(err)
+		}
+	}
+
+	// Sign out every device, the old password may have been compromised
+	err = s.deleteSessionsBySubjectId(user.Xid, impersonationEndPasswordReset)
+	if err != nil {
+		return err
+	}
+
+	s.log.Infof("Password has been reset. UserId=%d", user.Id)
+
+	return nil
+}
+
+// ChangePassword sets a new password of current user after checking the current one,
+// then revokes every other session of the user
+func (s *Service) ChangePassword(payload *dto.ChangePassword_Payload) error {
+	claims, err := s.verifyOwnUserSession()
+	if err != nil {
+		return err
+	}
+
+	user, err := s.getUserByXid(claims.Sub)
+	if err != nil {
+		return errk.Trace(err)
+	}
+
+	// Only users signing in with password can change it
+	credentials, err := s.findPasswordCredentials(user.Id)
+	if err != nil {
+		return errk.Trace(err)
+	}
+	if len(credentials) == 0 || !credentials[0].CredentialSecret.Valid {
+		s.log.Warnf("Password change requested for user without password. UserId=%d", user.Id)
+		return httpk.ForbiddenError
+	}
+
+	// Re-check current password, every identifier of the user shares the same password
+	if valid, _ := s.verifyPassword(credentials[0].CredentialSecret.String, payload.CurrentPassword); !valid {
+		s.log.Warnf("Invalid current password. UserId=%d", user.Id)
+		return specErr.IncorrectPassword
+	}
+
+	if err = s.validatePassword("newPassword", payload.NewPassword, user); err != nil {
+		return err
+	}
+
+	// Hash password
+	hash, err := s.hashPassword(payload.NewPassword)
+	if err != nil {
+		return errk.Trace(err)
+	}
+
+	for _, credential := range credentials {
+		err = s.repo.UpdateCredentialSecret(credential.Id, hash)
+		if err != nil {
+			s.log.Error("Failed to UpdateCredentialSecret", logkOption.Error(err))
+			return errk.Trace(err)
+		}
+	}
+
+	// Sign out other devices, keeping the session changing the password
+	sessions, err := s.repo.FindSessionsBySubjectId(claims.Sub)
+	if err != nil {
+		s.log.Error("Failed to FindSessionsBySubjectId", logkOption.Error(err))
+		return errk.Trace(err)
+	}
+	for _, session := range sessions {
+		if session.Xid == claims.Jti {
+			continue
+		}
+
+		err = s.auditImpersonationEnd(session, impersonationEndPasswordChange)
+		if err != nil {
+			return err
+		}
+
+		err = s.DeleteSession(session.Xid)
+		if err != nil {
+			return errk.Trace(err)
+		}
+	}
+
+	s.log.Infof("Password has been changed. UserId=%d", user.Id)
+
+	return nil
+}
+
+// composePasswordResetBody creates the message containing the reset link, or the token if reset URL is not configured
+func (s *Service) composePasswordResetBody(token string, lifetime time.Duration) string {
+	if s.config.PasswordResetUrl == "" {
+		return fmt.Sprintf("Use this token to reset your password: %s. It expires in %s.", token, lifetime)
+	}
+
+	link := s.config.PasswordResetUrl
+	if strings.Contains(link, "?") {
+		link += "&token=" + url.QueryEscape(token)
+	} else {
+		link += "?token=" + url.QueryEscape(token)
+	}
+
+	return fmt.Sprintf("Open this link to reset your password: %s. It expires in %s.", link, lifetime)
+}
+
+// validatePassword checks a new password against the password policy, returning every violation as validation errors
+func (s *Service) validatePassword(field string, password string, user *model.User) error {
+	violations := s.passwordPolicy.Check(password, pwpolicy.PersonalInfo{
+		FullName: user.FullName,
+		Email:    user.Email.String,
+		Username: user.Username.String,
+	})
+	if len(violations) == 0 {
+		return nil
+	}
+
+	errs := httpk.ValidationErrors{}
+	for _, v := range violations {
+		errs.Errors = append(errs.Errors, httpk.ValidationError{
+			Field:   field,
+			Tag:     v.Rule,
+			Message: fmt.Sprintf("%s %s", field, v.Message),
+		})
+	}
+
+	s.log.Warnf("Password rejected by policy. UserId=%d Violations=%s", user.Id, errs.Error())
+
+	return httpk.InvalidPayloadError.Wrap(errs)
+}
+
+// hashPassword hashes a password with the configured algorithm
+func (s *Service) hashPassword(password string) (string, error) {
+	hash, err := s.hasher.Hash(password)
+	if err != nil {
+		s.log.Error("Failed to hash password", logkOption.Error(err))
+		return "", errk.Trace(err)
+	}
+	return hash, nil
+}
+
+// verifyPassword checks a password against its hash. needsRehash is set if the password is valid
+// but the hash is produced by an outdated algorithm or parameters
+func (s *Service) verifyPassword(hash, password string) (valid bool, needsRehash bool) {
+	valid, needsRehash, err := s.hasher.Verify(hash, password)
+	if err != nil {
+		s.log.Error("Failed to verify password hash", logkOption.Error(err))
+		return false, false
+	}
+	return valid, needsRehash
+}
+
+// verifyDummyPassword takes the time of verifyPassword when there is no hash to verify the password against
+func (s *Service) verifyDummyPassword(password string) {
+	s.hasher.VerifyDummy(password)
+}
+
+// hashClientSecret hashes a client secret with bcrypt
+func (s *Service) hashClientSecret(secret string) (string, error) {
+	hash, err := s.clientSecretHasher.Hash(secret)
+	if err != nil {
+		s.log.Error("Failed to hash client secret", logkOption.Error(err))
+		return "", errk.Trace(err)
+	}
+	return hash, nil
+}
+
+// verifyClientSecretHash checks a client secret against its hash. needsRehash is set if the secret is valid but the
+// hash is not a bcrypt hash of the current cost, such as a secret hashed with argon2id
+func (s *Service) verifyClientSecretHash(hash, secret string) (valid bool, needsRehash bool) {
+	valid, needsRehash, err := s.clientSecretHasher.Verify(hash, secret)
+	if err != nil {
+		s.log.Error("Failed to verify client secret hash", logkOption.Error(err))
+		return false, false
+	}
+	return valid, needsRehash
+}
+
+// rehashPasswordCredentials upgrades the outdated hash shared by PASSWORD credentials of the user.
+// Failure is only logged, the user can still login with the outdated hash
+func (s *Service) rehashPasswordCredentials(userId int64, outdatedHash, password string) {
+	hash, err := s.hashPassword(password)
+	if err != nil {
+		return
+	}
+
+	credentials, err := s.findPasswordCredentials(userId)
+	if err != nil {
+		return
+	}
+
+	for _, credential := range credentials {
+		if credential.CredentialSecret.String != outdatedHash {
+			continue
+		}
+
+		err = s.repo.UpdateCredentialSecret(credential.Id, hash)
+		if err != nil {
+			s.log.Error("Failed to UpdateCredentialSecret on rehash", logkOption.Error(err))
+			return
+		}
+	}
+
+	s.log.Infof("Password hash upgraded. UserId=%d", userId)
+}
+
+// rehashClientSecret upgrades the outdated secret hash of a client. It is skipped if the client has been modified
+// since it was read, so a rotation is never undone. Failure is only logged
+func (s *Service) rehashClientSecret(clientAuth *model.ClientAuth, secret string) {
+	hash, err := s.hashClientSecret(secret)
+	if err != nil {
+		return
+	}
+
+	options := *clientAuth.Options
+	options.ClientSecret = hash
+
+	updated, err := s.repo.UpdateClientAuthOptions(clientAuth.Id, &options, clientAuth.Version)
+	if err != nil {
+		s.log.Error("Failed to UpdateClientAuthOptions on rehash", logkOption.Error(err))
+		return
+	}
+	if !updated {
+		s.log.Warnf("Client has been modified, secret hash is not upgraded. ClientId=%s", clientAuth.ClientId)
+		return
+	}
+
+	s.log.Infof("Client secret hash upgraded. ClientId=%s", clientAuth.ClientId)
+}
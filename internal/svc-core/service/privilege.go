@@ -0,0 +1,36 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-konsultin/errk"
+	logkOption "github.com/go-konsultin/logk/option"
+)
+
+// ValidatePrivilegeXids checks that every privilege xid is registered in Privilege table
+func (s *Service) ValidatePrivilegeXids(xids []string) error {
+	privileges, err := s.repo.FindAllPrivileges()
+	if err != nil {
+		s.log.Error("Failed to FindAllPrivileges", logkOption.Error(err))
+		return errk.Trace(err)
+	}
+
+	registered := make(map[string]struct{}, len(privileges))
+	for _, privilege := range privileges {
+		registered[privilege.Xid] = struct{}{}
+	}
+
+	var unknown []string
+	for _, xid := range xids {
+		if _, ok := registered[xid]; !ok {
+			unknown = append(unknown, xid)
+		}
+	}
+
+	if len(unknown) > 0 {
+		return fmt.Errorf("unknown privilege xid: %s", strings.Join(unknown, ", "))
+	}
+
+	return nil
+}
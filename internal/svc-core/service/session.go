@@ -0,0 +1,356 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"sort"
+
+	"github.com/go-konsultin/errk"
+	logkOption "github.com/go-konsultin/logk/option"
+	"github.com/konsultin/project-goes-here/dto"
+	"github.com/konsultin/project-goes-here/internal/svc-core/constant"
+	"github.com/konsultin/project-goes-here/internal/svc-core/model"
+	"github.com/konsultin/project-goes-here/internal/svc-core/pkg/httpk"
+	"github.com/konsultin/project-goes-here/internal/svc-core/pkg/valk"
+)
+
+// Logout deletes the session of current bearer token
+func (s *Service) Logout() error {
+	claims, err := s.verifyUserSession()
+	if err != nil {
+		return err
+	}
+
+	// Logging out of an impersonation session ends the impersonation
+	if claims.Act != nil {
+		session, err := s.repo.FindSessionByXid(claims.Jti)
+		if err != nil {
+			s.log.Error("Failed to FindSessionByXid", logkOption.Error(err))
+			return errk.Trace(err)
+		}
+		if session != nil {
+			err = s.auditImpersonationEnd(session, impersonationEndLogout)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	err = s.DeleteSession(claims.Jti)
+	if err != nil {
+		return errk.Trace(err)
+	}
+
+	s.log.Infof("User logged out. SubjectId=%s SessionXid=%s", claims.Sub, claims.Jti)
+
+	return nil
+}
+
+// LogoutAll deletes every session of current user, logging out all devices
+func (s *Service) LogoutAll() error {
+	claims, err := s.verifyOwnUserSession()
+	if err != nil {
+		return err
+	}
+
+	err = s.deleteSessionsBySubjectId(claims.Sub, impersonationEndLogoutAll)
+	if err != nil {
+		return err
+	}
+
+	s.log.Infof("User logged out from all devices. SubjectId=%s", claims.Sub)
+
+	return nil
+}
+
+// RevokeUserSessions deletes every session of the given user on behalf of an admin
+func (s *Service) RevokeUserSessions(userXid string) error {
+	admin, err := s.verifyAdminSession(constant.PrivilegeRevokeUserSession)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.getUserByXid(userXid)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return httpk.NotFoundError
+		}
+		return errk.Trace(err)
+	}
+
+	err = s.deleteSessionsBySubjectId(userXid, impersonationEndRevokedByAdmin)
+	if err != nil {
+		return err
+	}
+
+	s.log.Infof("User sessions revoked by admin. SubjectId=%s AdminId=%s", userXid, admin.Sub)
+
+	return nil
+}
+
+// ListMySessions returns every active session of current user, marking the session of current bearer token
+func (s *Service) ListMySessions() (*dto.ListActiveSession_Result, error) {
+	claims, err := s.verifyUserSession()
+	if err != nil {
+		return nil, err
+	}
+
+	sessions, err := s.repo.FindSessionsBySubjectId(claims.Sub)
+	if err != nil {
+		s.log.Error("Failed to FindSessionsBySubjectId", logkOption.Error(err))
+		return nil, errk.Trace(err)
+	}
+
+	result := make([]*dto.ActiveSession, 0, len(sessions))
+	for _, session := range sessions {
+		if session.StatusId != dto.ControlStatus_ACTIVE {
+			continue
+		}
+		result = append(result, composeActiveSessionResult(session, claims.Jti))
+	}
+
+	// Latest session first
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].CreatedAt > result[j].CreatedAt
+	})
+
+	return &dto.ListActiveSession_Result{
+		Sessions: result,
+	}, nil
+}
+
+// RevokeMySession deletes a session of current user, e.g. to sign out a lost device
+func (s *Service) RevokeMySession(xid string) error {
+	claims, err := s.verifyOwnUserSession()
+	if err != nil {
+		return err
+	}
+
+	session, err := s.repo.FindSessionByXid(xid)
+	if err != nil {
+		s.log.Error("Failed to FindSessionByXid", logkOption.Error(err))
+		return errk.Trace(err)
+	}
+
+	// Do not disclose sessions of other users
+	if session == nil || session.SubjectId != claims.Sub {
+		s.log.Warnf("Session not found for subject. SubjectId=%s SessionXid=%s", claims.Sub, xid)
+		return httpk.NotFoundError
+	}
+
+	err = s.auditImpersonationEnd(session, impersonationEndRevokedByUser)
+	if err != nil {
+		return err
+	}
+
+	err = s.DeleteSession(session.Xid)
+	if err != nil {
+		return errk.Trace(err)
+	}
+
+	s.log.Infof("User session revoked. SubjectId=%s SessionXid=%s", claims.Sub, xid)
+
+	return nil
+}
+
+// deactivateOtherSessions marks other active sessions of the subject as inactive, optionally only on the same device platform.
+// Inactive session is rejected with E_AUTH_3 on its next request
+func (s *Service) deactivateOtherSessions(current *model.AuthSession) error {
+	sessions, err := s.repo.FindSessionsBySubjectId(current.SubjectId)
+	if err != nil {
+		s.log.Error("Failed to FindSessionsBySubjectId", logkOption.Error(err))
+		return errk.Trace(err)
+	}
+
+	for _, session := range sessions {
+		// Impersonation sessions are not signed out by login of the user
+		if session.Xid == current.Xid || session.StatusId != dto.ControlStatus_ACTIVE || session.Actor != nil {
+			continue
+		}
+		if s.config.SingleDevicePerPlatform && session.DevicePlatformId != current.DevicePlatformId {
+			continue
+		}
+
+		session.StatusId = dto.ControlStatus_INACTIVE
+		err = s.repo.UpdateAuthSession(session)
+		if err != nil {
+			s.log.Error("Failed to UpdateAuthSession", logkOption.Error(err))
+			return errk.Trace(err)
+		}
+
+		s.log.Infof("Session deactivated by login from another device. SubjectId=%s SessionXid=%s", session.SubjectId, session.Xid)
+	}
+
+	return nil
+}
+
+// verifyUserSession checks if request has a valid user bearer token backed by an active session
+func (s *Service) verifyUserSession() (*JwtResponse, error) {
+	token, ok := s.ctx.Value(httpk.BearerToken).(string)
+	if !ok || token == "" {
+		s.log.Warn("Missing bearer token for user request")
+		return nil, httpk.UnauthorizedError
+	}
+
+	jwtAdapter := s.NewJwtAdapter()
+	claims, err := jwtAdapter.ValidateWithoutAudience(token)
+	if err != nil {
+		s.log.Error("Failed to validate user session token", logkOption.Error(err))
+		return nil, httpk.UnauthorizedError.Wrap(err)
+	}
+
+	if dto.Role_Enum(claims.Ent) != dto.Role_USER {
+		s.log.Warnf("Invalid session type for user request. Expected user, got: %d", claims.Ent)
+		return nil, httpk.UnauthorizedError
+	}
+
+	if isRefreshToken(claims) {
+		s.log.Warnf("Refresh token used for user request. SubjectId=%s", claims.Sub)
+		return nil, httpk.UnauthorizedError
+	}
+
+	session, err := s.repo.FindSessionByXid(claims.Jti)
+	if err != nil {
+		s.log.Error("Failed to FindSessionByXid", logkOption.Error(err))
+		return nil, errk.Trace(err)
+	}
+	if session == nil {
+		s.log.Warnf("No Session found. SessionXid=%s", claims.Jti)
+		return nil, httpk.UnauthorizedError
+	}
+
+	if err = s.isValidAuthSession(session); err != nil {
+		return nil, errk.Trace(err)
+	}
+
+	return claims, nil
+}
+
+// verifyAdminSession checks if request has a valid admin bearer token granted with the privilege
+func (s *Service) verifyAdminSession(privilege string) (*JwtResponse, error) {
+	token, ok := s.ctx.Value(httpk.BearerToken).(string)
+	if !ok || token == "" {
+		s.log.Warn("Missing bearer token for admin request")
+		return nil, httpk.UnauthorizedError
+	}
+
+	jwtAdapter := s.NewJwtAdapter()
+	claims, err := jwtAdapter.ValidateWithoutAudience(token)
+	if err != nil {
+		s.log.Error("Failed to validate admin session token", logkOption.Error(err))
+		return nil, httpk.UnauthorizedError.Wrap(err)
+	}
+
+	if dto.Role_Enum(claims.Ent) != dto.Role_ADMIN {
+		s.log.Warnf("Invalid session type for admin request. Expected admin, got: %d", claims.Ent)
+		return nil, httpk.ForbiddenError
+	}
+
+	if !valk.InArrayString(privilege, claims.Aud) {
+		s.log.Warnf("Admin session is not granted with privilege. SubjectId=%s Privilege=%s", claims.Sub, privilege)
+		return nil, httpk.ForbiddenError
+	}
+
+	return claims, nil
+}
+
+// AuthenticateBearerToken verifies a bearer token and returns the subject it was issued to with its granted privileges.
+// User and admin tokens must be backed by an active session, so revoked sessions stop working immediately.
+// Refresh tokens must be backed by a session or its rotation record, reuse of rotated refresh tokens is handled by
+// refresh session endpoint
+func (s *Service) AuthenticateBearerToken(token string) (*model.Subject, []string, error) {
+	jwtAdapter := s.NewJwtAdapter()
+	claims, err := jwtAdapter.ValidateWithoutAudience(token)
+	if err != nil {
+		s.log.Warnf("Failed to validate bearer token. Error=%v", err)
+		return nil, nil, httpk.UnauthorizedError.Wrap(err)
+	}
+
+	subjectType := dto.Role_Enum(claims.Ent)
+	subject := &model.Subject{
+		Id:   claims.Sub,
+		Role: dto.Role_Enum_name[int32(subjectType)],
+	}
+
+	if isRefreshToken(claims) {
+		ok, err := s.isRefreshTokenKnown(claims)
+		if err != nil {
+			return nil, nil, errk.Trace(err)
+		}
+		if !ok {
+			s.log.Warnf("No session or rotation found for refresh token. SubjectId=%s SessionXid=%s", claims.Sub, claims.Jti)
+			return nil, nil, httpk.UnauthorizedError
+		}
+		return subject, claims.Aud, nil
+	}
+
+	switch subjectType {
+	case dto.Role_ANONYMOUS_USER, dto.Role_ANONYMOUS_ADMIN, dto.Role_SERVICE:
+		// Anonymous and service sessions are not persisted, subject is the client.
+		// Revoked tokens and tokens of a deactivated client are rejected
+		active, err := s.isClientTokenActive(claims)
+		if err != nil {
+			return nil, nil, errk.Trace(err)
+		}
+		if !active {
+			s.log.Warnf("Bearer token is revoked or its client is inactive. ClientId=%s", claims.Sub)
+			return nil, nil, httpk.UnauthorizedError
+		}
+
+		subject.FullName = claims.Sub
+		return subject, claims.Aud, nil
+	case dto.Role_USER, dto.Role_ADMIN:
+		// Do nothing
+	default:
+		s.log.Warnf("Unexpected bearer token subject type. SubjectType=%d", subjectType)
+		return nil, nil, httpk.UnauthorizedError
+	}
+
+	session, err := s.repo.FindSessionByXid(claims.Jti)
+	if err != nil {
+		s.log.Error("Failed to FindSessionByXid", logkOption.Error(err))
+		return nil, nil, errk.Trace(err)
+	}
+	if session == nil || session.SubjectId != claims.Sub {
+		s.log.Warnf("No Session found for bearer token. SubjectId=%s SessionXid=%s", claims.Sub, claims.Jti)
+		return nil, nil, httpk.UnauthorizedError
+	}
+
+	if err = s.isValidAuthSession(session); err != nil {
+		return nil, nil, err
+	}
+	if session.StatusId != dto.ControlStatus_ACTIVE {
+		s.log.Warnf("Session is not active. SubjectId=%s SessionXid=%s StatusId=%d", claims.Sub, claims.Jti, session.StatusId)
+		return nil, nil, httpk.UnauthorizedError
+	}
+
+	subject.FullName = session.SubjectFullName
+	subject.Actor = session.Actor
+
+	return subject, claims.Aud, nil
+}
+
+// isRefreshTokenKnown checks that a refresh token belongs to a live session of its subject, or to a rotated one
+func (s *Service) isRefreshTokenKnown(claims *JwtResponse) (bool, error) {
+	session, err := s.repo.FindSessionByXid(claims.Jti)
+	if err != nil {
+		s.log.Error("Failed to FindSessionByXid", logkOption.Error(err))
+		return false, errk.Trace(err)
+	}
+	if session != nil {
+		return session.SubjectId == claims.Sub, nil
+	}
+
+	rotation, err := s.repo.FindRefreshTokenRotation(claims.Jti)
+	if err != nil {
+		s.log.Error("Failed to FindRefreshTokenRotation", logkOption.Error(err))
+		return false, errk.Trace(err)
+	}
+
+	return rotation != nil && rotation.SubjectId == claims.Sub, nil
+}
+
+// isRefreshToken checks if the token is a refresh token, which is only granted to refresh user session
+func isRefreshToken(claims *JwtResponse) bool {
+	return len(claims.Aud) == 1 && claims.Aud[0] == constant.PrivilegeRefreshUserToken
+}
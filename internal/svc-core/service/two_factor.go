@@ -0,0 +1,500 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/go-konsultin/errk"
+	logkOption "github.com/go-konsultin/logk/option"
+	"github.com/go-konsultin/timek"
+	"github.com/konsultin/project-goes-here/dto"
+	specErr "github.com/konsultin/project-goes-here/internal/errors"
+	"github.com/konsultin/project-goes-here/internal/svc-core/model"
+	"github.com/konsultin/project-goes-here/internal/svc-core/pkg/httpk"
+	"github.com/konsultin/project-goes-here/internal/svc-core/pkg/svck"
+	"github.com/konsultin/project-goes-here/internal/svc-core/pkg/totp"
+	gonanoid "github.com/matoous/go-nanoid/v2"
+)
+
+// Periods before and after current time a TOTP code is accepted, tolerating clock drift of the device
+const totpSkew = 1
+
+// EnrollTotp creates a TOTP secret for current user. Two-factor authentication is enabled once confirmed with a code,
+// enrolling again before confirming replaces the secret
+func (s *Service) EnrollTotp() (*dto.EnrollTotp_Result, error) {
+	claims, err := s.verifyOwnUserSession()
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.getUserByXid(claims.Sub)
+	if err != nil {
+		return nil, errk.Trace(err)
+	}
+
+	credential, err := s.findTotpCredential(user)
+	if err != nil {
+		return nil, errk.Trace(err)
+	}
+	if credential != nil {
+		if credential.IsVerified {
+			return nil, specErr.TwoFactorAlreadyEnabled
+		}
+
+		err = s.repo.DeleteUserCredential(credential.Id)
+		if err != nil {
+			s.log.Error("Failed to DeleteUserCredential", logkOption.Error(err))
+			return nil, errk.Trace(err)
+		}
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, errk.Trace(err)
+	}
+
+	now := timek.Now()
+	err = s.repo.InsertUserCredential(&model.UserCredential{
+		UserId:           user.Id,
+		AuthProviderId:   dto.AuthProvider_TOTP,
+		CredentialKey:    user.Xid,
+		CredentialSecret: sql.NullString{String: secret, Valid: true},
+		CreatedAt:        now,
+		UpdatedAt:        now,
+	})
+	if err != nil {
+		s.log.Error("Failed to insert user credential", logkOption.Error(err))
+		return nil, errk.Trace(err)
+	}
+
+	issuer := s.config.TotpIssuer
+	if issuer == "" {
+		issuer = s.config.JwtIssuer
+	}
+
+	return &dto.EnrollTotp_Result{
+		Secret: secret,
+		Uri:    totp.URI(issuer, composeTotpAccount(user), secret),
+	}, nil
+}
+
+// ConfirmTotp enables two-factor authentication of current user with a code of the enrolled secret,
+// returning the recovery codes
+func (s *Service) ConfirmTotp(payload *dto.TwoFactorCode_Payload) (*dto.RecoveryCodes_Result, error) {
+	claims, err := s.verifyOwnUserSession()
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.getUserByXid(claims.Sub)
+	if err != nil {
+		return nil, errk.Trace(err)
+	}
+
+	credential, err := s.findTotpCredential(user)
+	if err != nil {
+		return nil, errk.Trace(err)
+	}
+	if credential == nil {
+		return nil, specErr.TwoFactorNotEnabled
+	}
+	if credential.IsVerified {
+		return nil, specErr.TwoFactorAlreadyEnabled
+	}
+
+	if err = s.checkTwoFactorFailure(user); err != nil {
+		return nil, err
+	}
+
+	valid, err := s.validateTotpCode(credential, payload.Code)
+	if err != nil {
+		return nil, errk.Trace(err)
+	}
+	if !valid {
+		s.log.Warnf("Invalid TOTP code on enrollment. UserId=%d", user.Id)
+		return nil, s.failTwoFactor(user)
+	}
+
+	if err = s.clearTwoFactorFailure(user); err != nil {
+		return nil, errk.Trace(err)
+	}
+
+	err = s.repo.UpdateCredentialVerified(credential.Id)
+	if err != nil {
+		s.log.Error("Failed to UpdateCredentialVerified", logkOption.Error(err))
+		return nil, errk.Trace(err)
+	}
+
+	codes, err := s.generateRecoveryCodes(user.Id)
+	if err != nil {
+		return nil, errk.Trace(err)
+	}
+
+	s.log.Infof("Two-factor authentication enabled. UserId=%d", user.Id)
+
+	return &dto.RecoveryCodes_Result{RecoveryCodes: codes}, nil
+}
+
+// RegenerateRecoveryCodes replaces recovery codes of current user, the previous codes stop working
+func (s *Service) RegenerateRecoveryCodes(payload *dto.TwoFactorCode_Payload) (*dto.RecoveryCodes_Result, error) {
+	user, credential, err := s.verifyTwoFactorOfCurrentUser(payload.Code)
+	if err != nil {
+		return nil, err
+	}
+
+	codes, err := s.generateRecoveryCodes(user.Id)
+	if err != nil {
+		return nil, errk.Trace(err)
+	}
+
+	s.log.Infof("Recovery codes regenerated. UserId=%d CredentialId=%d", user.Id, credential.Id)
+
+	return &dto.RecoveryCodes_Result{RecoveryCodes: codes}, nil
+}
+
+// DisableTotp turns off two-factor authentication of current user, deleting its secret and recovery codes
+func (s *Service) DisableTotp(payload *dto.TwoFactorCode_Payload) error {
+	user, credential, err := s.verifyTwoFactorOfCurrentUser(payload.Code)
+	if err != nil {
+		return err
+	}
+
+	err = s.repo.DeleteUserCredential(credential.Id)
+	if err != nil {
+		s.log.Error("Failed to DeleteUserCredential", logkOption.Error(err))
+		return errk.Trace(err)
+	}
+
+	err = s.repo.DeleteUserRecoveryCodes(user.Id)
+	if err != nil {
+		s.log.Error("Failed to DeleteUserRecoveryCodes", logkOption.Error(err))
+		return errk.Trace(err)
+	}
+
+	s.log.Infof("Two-factor authentication disabled. UserId=%d", user.Id)
+
+	return nil
+}
+
+// LoginTwoFactor exchanges a two-factor challenge and a TOTP or recovery code for the user session.
+// Challenge is dropped after too many invalid codes, which also count as failed logins of the identifier
+// Requires anonymous session bearer token for authentication
+func (s *Service) LoginTwoFactor(payload *dto.LoginTwoFactor_Payload) (*dto.CreateUserSession_Result_Data, error) {
+	// Verify anonymous session token first
+	if err := s.verifyAnonymousSession(); err != nil {
+		return nil, err
+	}
+
+	tokenHash := hashSecretToken(payload.ChallengeToken)
+	challenge, err := s.repo.FindTwoFactorChallenge(tokenHash)
+	if err != nil {
+		s.log.Error("Failed to FindTwoFactorChallenge", logkOption.Error(err))
+		return nil, errk.Trace(err)
+	}
+	if challenge == nil {
+		s.log.Warn("Two-factor challenge is invalid or has expired")
+		return nil, specErr.InvalidTwoFactorChallenge
+	}
+
+	user, err := s.getUserById(challenge.UserId)
+	if err != nil {
+		return nil, errk.Trace(err)
+	}
+	if err = s.expireUserLockout(user); err != nil {
+		return nil, errk.Trace(err)
+	}
+	if user.StatusId != dto.ControlStatus_ACTIVE {
+		s.log.Warnf("User account is not active. UserId=%d Status=%d", user.Id, user.StatusId)
+		return nil, httpk.ForbiddenError
+	}
+
+	// Second factor may have been disabled since the challenge was issued
+	credential, err := s.findTotpCredential(user)
+	if err != nil {
+		return nil, errk.Trace(err)
+	}
+	if credential == nil || !credential.IsVerified {
+		s.log.Warnf("Two-factor authentication is no longer enabled. UserId=%d", user.Id)
+		return nil, specErr.InvalidTwoFactorChallenge
+	}
+
+	// Count attempt before verifying, so concurrent attempts can not go over the limit
+	attempts, err := s.repo.IncrTwoFactorAttempt(tokenHash, time.Duration(s.config.TwoFactorChallengeLifetime)*time.Second)
+	if err != nil {
+		s.log.Error("Failed to IncrTwoFactorAttempt", logkOption.Error(err))
+		return nil, errk.Trace(err)
+	}
+	if attempts > int64(s.config.TwoFactorMaxAttempts) {
+		s.log.Warnf("Too many two-factor attempts. UserId=%d", user.Id)
+		if _, err = s.discardTwoFactorChallenge(tokenHash); err != nil {
+			return nil, errk.Trace(err)
+		}
+		return nil, specErr.InvalidTwoFactorChallenge
+	}
+
+	valid, err := s.verifySecondFactor(user, credential, payload.Code)
+	if err != nil {
+		return nil, errk.Trace(err)
+	}
+	if !valid {
+		// Count as failed login, so issuing new challenges does not allow guessing codes without limit
+		if err = s.recordLoginFailure(challenge.Identifier, user); err != nil {
+			return nil, errk.Trace(err)
+		}
+
+		// Challenge is dropped with the last allowed attempt
+		if attempts == int64(s.config.TwoFactorMaxAttempts) {
+			s.log.Warnf("Too many invalid two-factor codes. UserId=%d", user.Id)
+			if _, err = s.discardTwoFactorChallenge(tokenHash); err != nil {
+				return nil, errk.Trace(err)
+			}
+		}
+		return nil, specErr.InvalidTwoFactorCode
+	}
+
+	// Challenge is single use
+	consumed, err := s.discardTwoFactorChallenge(tokenHash)
+	if err != nil {
+		return nil, errk.Trace(err)
+	}
+	if !consumed {
+		s.log.Warnf("Two-factor challenge has been consumed. UserId=%d", user.Id)
+		return nil, specErr.InvalidTwoFactorChallenge
+	}
+
+	if err = s.clearLoginFailure(challenge.Identifier, user); err != nil {
+		return nil, errk.Trace(err)
+	}
+
+	return s.CreateUserSession(user, challenge.AuthProviderId, challenge.Device, time.Now())
+}
+
+// discardTwoFactorChallenge drops a two-factor challenge with its attempts. Returns false if it was already consumed or has expired
+func (s *Service) discardTwoFactorChallenge(tokenHash string) (bool, error) {
+	consumed, err := s.repo.ConsumeTwoFactorChallenge(tokenHash)
+	if err != nil {
+		s.log.Error("Failed to ConsumeTwoFactorChallenge", logkOption.Error(err))
+		return false, errk.Trace(err)
+	}
+
+	err = s.repo.DeleteTwoFactorAttempt(tokenHash)
+	if err != nil {
+		s.log.Error("Failed to DeleteTwoFactorAttempt", logkOption.Error(err))
+		return false, errk.Trace(err)
+	}
+
+	return consumed, nil
+}
+
+// isTwoFactorEnabled checks if user has confirmed a TOTP secret
+func (s *Service) isTwoFactorEnabled(user *model.User) (bool, error) {
+	credential, err := s.findTotpCredential(user)
+	if err != nil {
+		return false, errk.Trace(err)
+	}
+	return credential != nil && credential.IsVerified, nil
+}
+
+// createTwoFactorChallenge issues a challenge token to complete login of the user with a second factor
+func (s *Service) createTwoFactorChallenge(user *model.User, authProviderId dto.AuthProvider_Enum, identifier string, device *dto.DeviceSession) (*dto.CreateUserSession_Result_Data, error) {
+	token, err := generateSecretToken()
+	if err != nil {
+		return nil, errk.Trace(err)
+	}
+
+	now := time.Now()
+	lifetime := time.Duration(s.config.TwoFactorChallengeLifetime) * time.Second
+	err = s.repo.InsertTwoFactorChallenge(hashSecretToken(token), &model.TwoFactorChallenge{
+		UserId:         user.Id,
+		AuthProviderId: authProviderId,
+		Identifier:     identifier,
+		Device:         device,
+		CreatedAt:      now,
+	}, lifetime)
+	if err != nil {
+		s.log.Error("Failed to InsertTwoFactorChallenge", logkOption.Error(err))
+		return nil, errk.Trace(err)
+	}
+
+	s.log.Infof("Two-factor challenge issued. UserId=%d", user.Id)
+
+	return &dto.CreateUserSession_Result_Data{
+		TwoFactorChallenge: &dto.TwoFactorChallenge{
+			Token:     token,
+			ExpiredAt: now.Add(lifetime).Unix(),
+		},
+	}, nil
+}
+
+// verifyTwoFactorOfCurrentUser checks a TOTP or recovery code of current user with two-factor authentication enabled
+func (s *Service) verifyTwoFactorOfCurrentUser(code string) (*model.User, *model.UserCredential, error) {
+	claims, err := s.verifyOwnUserSession()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	user, err := s.getUserByXid(claims.Sub)
+	if err != nil {
+		return nil, nil, errk.Trace(err)
+	}
+
+	credential, err := s.findTotpCredential(user)
+	if err != nil {
+		return nil, nil, errk.Trace(err)
+	}
+	if credential == nil || !credential.IsVerified {
+		return nil, nil, specErr.TwoFactorNotEnabled
+	}
+
+	if err = s.checkTwoFactorFailure(user); err != nil {
+		return nil, nil, err
+	}
+
+	valid, err := s.verifySecondFactor(user, credential, code)
+	if err != nil {
+		return nil, nil, errk.Trace(err)
+	}
+	if !valid {
+		s.log.Warnf("Invalid two-factor code. UserId=%d", user.Id)
+		return nil, nil, s.failTwoFactor(user)
+	}
+
+	if err = s.clearTwoFactorFailure(user); err != nil {
+		return nil, nil, errk.Trace(err)
+	}
+
+	return user, credential, nil
+}
+
+// checkTwoFactorFailure rejects two-factor code of signed in user without checking it after too many invalid codes
+func (s *Service) checkTwoFactorFailure(user *model.User) error {
+	count, err := s.repo.FindTwoFactorFailure(user.Id)
+	if err != nil {
+		s.log.Error("Failed to FindTwoFactorFailure", logkOption.Error(err))
+		return errk.Trace(err)
+	}
+	if count >= int64(s.config.TwoFactorMaxAttempts) {
+		s.log.Warnf("Too many invalid two-factor codes. UserId=%d", user.Id)
+		return specErr.TooManyTwoFactorAttempts
+	}
+	return nil
+}
+
+// failTwoFactor counts an invalid two-factor code of signed in user and returns the error rejecting it
+func (s *Service) failTwoFactor(user *model.User) error {
+	_, err := s.repo.IncrTwoFactorFailure(user.Id, time.Duration(s.config.LoginFailureWindow)*time.Second)
+	if err != nil {
+		s.log.Error("Failed to IncrTwoFactorFailure", logkOption.Error(err))
+		return errk.Trace(err)
+	}
+	return specErr.InvalidTwoFactorCode
+}
+
+// clearTwoFactorFailure resets invalid two-factor code counter of the user after a valid code
+func (s *Service) clearTwoFactorFailure(user *model.User) error {
+	err := s.repo.DeleteTwoFactorFailure(user.Id)
+	if err != nil {
+		s.log.Error("Failed to DeleteTwoFactorFailure", logkOption.Error(err))
+		return errk.Trace(err)
+	}
+	return nil
+}
+
+// verifySecondFactor checks a TOTP code, or redeems a recovery code if it is not a TOTP code
+func (s *Service) verifySecondFactor(user *model.User, credential *model.UserCredential, code string) (bool, error) {
+	code = strings.TrimSpace(code)
+	if len(code) == totp.Digits && strings.Trim(code, svck.NumCharSet) == "" {
+		return s.validateTotpCode(credential, code)
+	}
+
+	used, err := s.repo.UseUserRecoveryCode(user.Id, hashSecretToken(normalizeRecoveryCode(code)))
+	if err != nil {
+		s.log.Error("Failed to UseUserRecoveryCode", logkOption.Error(err))
+		return false, errk.Trace(err)
+	}
+	if used {
+		s.log.Infof("Recovery code used. UserId=%d", user.Id)
+	}
+
+	return used, nil
+}
+
+// validateTotpCode checks a TOTP code of the credential, rejecting a code that has been used before
+func (s *Service) validateTotpCode(credential *model.UserCredential, code string) (bool, error) {
+	step, ok := totp.Validate(credential.CredentialSecret.String, code, time.Now(), totpSkew)
+	if !ok {
+		return false, nil
+	}
+
+	// Keep used step until it falls out of the accepted window
+	lifetime := time.Duration((2*totpSkew+1)*totp.Period) * time.Second
+	fresh, err := s.repo.InsertTotpUsedStep(credential.Id, step, lifetime)
+	if err != nil {
+		s.log.Error("Failed to InsertTotpUsedStep", logkOption.Error(err))
+		return false, errk.Trace(err)
+	}
+	if !fresh {
+		s.log.Warnf("TOTP code has been used. CredentialId=%d", credential.Id)
+	}
+
+	return fresh, nil
+}
+
+// generateRecoveryCodes replaces recovery codes of the user, returning the plain codes. Only their hashes are stored
+func (s *Service) generateRecoveryCodes(userId int64) ([]string, error) {
+	now := timek.Now()
+	codes := make([]string, s.config.RecoveryCodeCount)
+	rows := make([]*model.UserRecoveryCode, s.config.RecoveryCodeCount)
+	for i := range codes {
+		code, err := gonanoid.Generate(svck.AlphaNumCharSet, 10)
+		if err != nil {
+			return nil, errk.Trace(err)
+		}
+
+		codes[i] = code[:5] + "-" + code[5:]
+		rows[i] = &model.UserRecoveryCode{
+			UserId:    userId,
+			CodeHash:  hashSecretToken(code),
+			CreatedAt: now,
+		}
+	}
+
+	err := s.repo.ReplaceUserRecoveryCodes(userId, rows)
+	if err != nil {
+		s.log.Error("Failed to ReplaceUserRecoveryCodes", logkOption.Error(err))
+		return nil, errk.Trace(err)
+	}
+
+	return codes, nil
+}
+
+// findTotpCredential returns TOTP credential of the user, nil if user has not enrolled
+func (s *Service) findTotpCredential(user *model.User) (*model.UserCredential, error) {
+	credential, err := s.repo.FindCredentialByKey(dto.AuthProvider_TOTP, user.Xid)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		s.log.Error("Failed to find TOTP credential", logkOption.Error(err))
+		return nil, errk.Trace(err)
+	}
+	return credential, nil
+}
+
+// composeTotpAccount returns the account name shown in authenticator apps
+func composeTotpAccount(user *model.User) string {
+	for _, identifier := range []string{user.Email.String, user.Phone.String, user.Username.String} {
+		if identifier != "" {
+			return identifier
+		}
+	}
+	return user.Xid
+}
+
+// normalizeRecoveryCode removes separators and case from a recovery code typed by user
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
@@ -16,7 +16,6 @@ import (
 	unaryHttpk "github.com/konsultin/project-goes-here/internal/svc-core/pkg/httpk/unary"
 	"github.com/konsultin/project-goes-here/internal/svc-core/pkg/svck"
 	gonanoid "github.com/matoous/go-nanoid/v2"
-	"golang.org/x/crypto/bcrypt"
 )
 
 func (s *Service) CreateAnonymousUserSession(payload *unaryHttpk.BasicAuth, clientTypeId dto.Role_Enum) (*dto.CreateUserSession_Result, error) {
@@ -30,10 +29,15 @@ func (s *Service) CreateAnonymousUserSession(payload *unaryHttpk.BasicAuth, clie
 		return nil, errk.Trace(err)
 	}
 
-	err = bcrypt.CompareHashAndPassword([]byte(clientAuth.Options.ClientSecret), []byte(payload.Password))
-	if err != nil {
-		s.log.Error("Failed to compare the password", logkOption.Error(err))
-		return nil, specErr.InvalidCredentials.Wrap(err).Trace()
+	if !s.verifyClientSecret(clientAuth, payload.Password) {
+		s.log.Errorf("Invalid client secret. ClientId = %s", clientAuth.ClientId)
+		return nil, specErr.InvalidCredentials.Trace()
+	}
+
+	// Status is checked after the secret, so it is not disclosed to unauthenticated callers
+	if clientAuth.StatusId != dto.ControlStatus_ACTIVE {
+		s.log.Warnf("Client is not active. ClientId = %s StatusId = %d", clientAuth.ClientId, clientAuth.StatusId)
+		return nil, specErr.InactiveClient.Trace()
 	}
 
 	if clientAuth.ClientTypeId != clientTypeId {
@@ -97,6 +101,16 @@ func (s *Service) RefreshUserSession(payload *dto.RefreshSession_Payload) (*dto.
 		return nil, httpk.UnauthorizedError.Wrap(err).Trace()
 	}
 
+	// Check if refresh token has already been rotated
+	rotation, err := s.repo.FindRefreshTokenRotation(jwtToken.Jti)
+	if err != nil {
+		s.log.Error("Failed to FindRefreshTokenRotation", logkOption.Error(err))
+		return nil, errk.Trace(err)
+	}
+	if rotation != nil {
+		return s.refreshRotatedUserSession(rotation, payload.Device)
+	}
+
 	// get user by xid from token
 	user, err := s.getUserByXid(jwtToken.Sub)
 	if err != nil {
@@ -106,19 +120,53 @@ func (s *Service) RefreshUserSession(payload *dto.RefreshSession_Payload) (*dto.
 	// get session by xid from token
 	session, err := s.repo.FindSessionByXid(jwtToken.Jti)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			s.log.Error("No Session found", logkOption.Error(err))
-			return nil, httpk.UnauthorizedError.Wrap(err).Trace()
-		}
 		s.log.Error("Failed to FindSessionByXid", logkOption.Error(err))
 		return nil, errk.Trace(err)
 	}
+	if session == nil {
+		s.log.Warnf("No Session found. SessionXid=%s", jwtToken.Jti)
+		return nil, httpk.UnauthorizedError
+	}
 	// Check Auth Session status
 	if err = s.isValidAuthSession(session); err != nil {
 		return nil, errk.Trace(err)
 	}
-	// Create new user session
-	data, err := s.CreateUserSession(user, session.AuthProviderId, payload.Device, time.Now())
+
+	// Sessions created before token families were tracked start their own family
+	familyId := session.FamilyId
+	if familyId == "" {
+		familyId = session.Xid
+	}
+
+	// Mark refresh token as rotated, only one of concurrent refreshes wins
+	rotation = &model.RefreshTokenRotation{
+		SessionXid:     session.Xid,
+		FamilyId:       familyId,
+		SubjectId:      session.SubjectId,
+		AuthProviderId: session.AuthProviderId,
+		DeviceId:       session.DeviceId,
+		ClientId:       jwtToken.ClientId,
+		RotatedAt:      time.Now(),
+	}
+	ok, err = s.repo.InsertRefreshTokenRotation(rotation)
+	if err != nil {
+		s.log.Error("Failed to InsertRefreshTokenRotation", logkOption.Error(err))
+		return nil, errk.Trace(err)
+	}
+	if !ok {
+		rotation, err = s.repo.FindRefreshTokenRotation(session.Xid)
+		if err != nil {
+			s.log.Error("Failed to FindRefreshTokenRotation", logkOption.Error(err))
+			return nil, errk.Trace(err)
+		}
+		if rotation == nil {
+			return nil, httpk.UnauthorizedError
+		}
+		return s.refreshRotatedUserSession(rotation, payload.Device)
+	}
+
+	// Create new user session in the same token family
+	data, err := s.createUserSession(user, session.AuthProviderId, payload.Device, time.Now(), familyId, jwtToken.ClientId)
 	if err != nil {
 		s.log.Error("Failed to CreateUserSession", logkOption.Error(err))
 		return nil, errk.Trace(err)
@@ -134,6 +182,65 @@ func (s *Service) RefreshUserSession(payload *dto.RefreshSession_Payload) (*dto.
 	return data, nil
 }
 
+// refreshRotatedUserSession handles a refresh token presented again after it has been rotated.
+// Within the grace period the same device may receive another session of the family, e.g. on concurrent refreshes.
+// A request without device id can not be matched to the device, so it never gets the grace period.
+// Otherwise the token is considered stolen, and every session in its family is revoked
+func (s *Service) refreshRotatedUserSession(rotation *model.RefreshTokenRotation, device *dto.DeviceSession) (*dto.CreateUserSession_Result_Data, error) {
+	deviceId := ""
+	if device != nil {
+		deviceId = device.DeviceId
+	}
+
+	gracePeriod := time.Duration(s.config.RefreshTokenReuseGracePeriod) * time.Second
+	if deviceId != "" && time.Since(rotation.RotatedAt) <= gracePeriod && deviceId == rotation.DeviceId {
+		// Family may have been revoked in the meantime
+		alive, err := s.repo.ExistsTokenFamily(rotation.FamilyId)
+		if err != nil {
+			s.log.Error("Failed to ExistsTokenFamily", logkOption.Error(err))
+			return nil, errk.Trace(err)
+		}
+
+		if alive {
+			user, err := s.getUserByXid(rotation.SubjectId)
+			if err != nil {
+				return nil, httpk.UnauthorizedError.Wrap(err).Trace()
+			}
+
+			s.log.Infof("Rotated refresh token reused within grace period. SubjectId=%s SessionXid=%s FamilyId=%s",
+				rotation.SubjectId, rotation.SessionXid, rotation.FamilyId)
+
+			return s.createUserSession(user, rotation.AuthProviderId, device, time.Now(), rotation.FamilyId, rotation.ClientId)
+		}
+	}
+
+	// Reuse detected, revoke the whole token family
+	s.log.Warnf("Rotated refresh token reused, revoking token family. SubjectId=%s SessionXid=%s FamilyId=%s ClientIp=%s",
+		rotation.SubjectId, rotation.SessionXid, rotation.FamilyId, s.clientIp)
+
+	err := s.repo.DeleteSessionsByFamilyId(rotation.SubjectId, rotation.FamilyId)
+	if err != nil {
+		s.log.Error("Failed to DeleteSessionsByFamilyId", logkOption.Error(err))
+		return nil, errk.Trace(err)
+	}
+
+	err = s.repo.PublishSecurityEvent(&dto.SecurityEvent{
+		Type:       constant.SecurityEventRefreshTokenReuse,
+		SubjectId:  rotation.SubjectId,
+		SessionXid: rotation.SessionXid,
+		FamilyId:   rotation.FamilyId,
+		DeviceId:   deviceId,
+		ClientIp:   s.clientIp,
+		OccurredAt: time.Now().Unix(),
+	})
+	if err != nil {
+		// Family is already revoked, do not fail the request on notification error
+		s.log.Error("Failed to PublishSecurityEvent", logkOption.Error(err))
+	}
+
+	return nil, httpk.UnauthorizedError
+}
+
 func (s *Service) isValidAuthSession(session *model.AuthSession) error {
 	switch session.StatusId {
 	case dto.ControlStatus_ACTIVE:
@@ -173,7 +280,14 @@ func (s *Service) DeleteSession(xid string) error {
 	return nil
 }
 
+// CreateUserSession issues access and refresh token of a new session, starting a new refresh token family
 func (s *Service) CreateUserSession(user *model.User, authProviderId dto.AuthProvider_Enum, device *dto.DeviceSession, t time.Time) (*dto.CreateUserSession_Result_Data, error) {
+	return s.createUserSession(user, authProviderId, device, t, "", s.loginClientId())
+}
+
+// createUserSession issues a new session in the refresh token family, an empty familyId starts a new family.
+// Tokens carry the client the session is issued through, so only that client can revoke them
+func (s *Service) createUserSession(user *model.User, authProviderId dto.AuthProvider_Enum, device *dto.DeviceSession, t time.Time, familyId string, clientId string) (*dto.CreateUserSession_Result_Data, error) {
 	// Get user privileges
 	subjectType := int32(dto.Role_USER)
 	rolePrivileges, err := s.repo.FindRolePrivilegeByRoleId(subjectType)
@@ -189,6 +303,10 @@ func (s *Service) CreateUserSession(user *model.User, authProviderId dto.AuthPro
 	// Get created At
 	createdAt := sql.NullTime{Time: t, Valid: true}
 	sessionId := gonanoid.MustGenerate(svck.AlphaNumUpperCharSet, 10)
+	isNewLogin := familyId == ""
+	if isNewLogin {
+		familyId = sessionId
+	}
 	jwtAdapter := s.NewJwtAdapter()
 	// Issue the JWT for Access Token
 	accessSession, err := jwtAdapter.Issue(IssueJwtPayload{
@@ -198,6 +316,7 @@ func (s *Service) CreateUserSession(user *model.User, authProviderId dto.AuthPro
 		Lifetime:    s.config.UserSessionLifetime,
 		SubjectType: subjectType,
 		CreatedAt:   createdAt,
+		ClientId:    clientId,
 	})
 
 	// Issue the JWT for Refresh Token
@@ -208,11 +327,17 @@ func (s *Service) CreateUserSession(user *model.User, authProviderId dto.AuthPro
 		Lifetime:    s.config.UserSessionRefreshLifetime,
 		SubjectType: subjectType,
 		CreatedAt:   createdAt,
+		ClientId:    clientId,
 	})
 
 	// Init baseField
 	baseField := model.NewBaseFieldFromModel(s.subject)
 
+	// Device is optional on login payloads
+	if device == nil {
+		device = &dto.DeviceSession{}
+	}
+
 	// FCM Token
 	notificationToken := sql.NullString{}
 	if device.NotificationToken != "" {
@@ -223,15 +348,17 @@ func (s *Service) CreateUserSession(user *model.User, authProviderId dto.AuthPro
 	authSession := &model.AuthSession{
 		BaseField:        baseField,
 		Xid:              sessionId,
+		FamilyId:         familyId,
 		SubjectId:        user.Xid,
 		SubjectTypeId:    dto.Role_Enum(subjectType),
+		SubjectFullName:  user.FullName,
 		AuthProviderId:   authProviderId,
 		DevicePlatformId: device.DevicePlatformId,
 		DeviceId:         device.DeviceId,
 		Device: &model.AuthSessionDevice{
 			DeviceId:         device.DeviceId,
 			DevicePlatformId: device.DevicePlatformId,
-			ClientIp:         "",
+			ClientIp:         s.clientIp,
 		},
 		NotificationChannelId: device.NotificationChannelId,
 		NotificationToken:     notificationToken,
@@ -246,6 +373,14 @@ func (s *Service) CreateUserSession(user *model.User, authProviderId dto.AuthPro
 		return nil, errk.Trace(err)
 	}
 
+	// Sign out other devices on login, refreshed sessions keep their family
+	if s.config.FeatureFlagSingleDevice && isNewLogin {
+		err = s.deactivateOtherSessions(authSession)
+		if err != nil {
+			return nil, errk.Trace(err)
+		}
+	}
+
 	return &dto.CreateUserSession_Result_Data{
 		User:           s.mustComposeUserResult(user),
 		AccessSession:  accessSession,
@@ -1,6 +1,15 @@
 package service
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+
 	"github.com/konsultin/project-goes-here/dto"
 	"github.com/konsultin/project-goes-here/internal/svc-core/model"
 	"github.com/konsultin/project-goes-here/internal/svc-core/pkg/svck"
@@ -29,6 +38,19 @@ func (s *Service) getUserById(id int64) (*model.User, error) {
 	return user, nil
 }
 
+// isIdentifierTaken checks whether email/phone/username is already used by another user
+func (s *Service) isIdentifierTaken(identifier string) (bool, error) {
+	_, err := s.repo.FindUserByIdentifier(identifier)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		s.log.Error("Failed to FindUserByIdentifier", logkOption.Error(err))
+		return false, errk.Trace(err)
+	}
+	return true, nil
+}
+
 // generateXid generates a new XID for user
 func (s *Service) generateXid() string {
 	return gonanoid.MustGenerate(svck.AlphaNumUpperCharSet, 12)
@@ -54,3 +76,69 @@ func composeControlStatusResult(statusId dto.ControlStatus_Enum) *dto.Status {
 		Name: name,
 	}
 }
+
+// composeActiveSessionResult creates an ActiveSession DTO, currentXid is the session xid of current bearer token
+func composeActiveSessionResult(m *model.AuthSession, currentXid string) *dto.ActiveSession {
+	clientIp := ""
+	if m.Device != nil {
+		clientIp = m.Device.ClientIp
+	}
+
+	return &dto.ActiveSession{
+		Xid: m.Xid,
+		DevicePlatform: &dto.DevicePlatform_Result{
+			Id:   m.DevicePlatformId,
+			Name: dto.DevicePlatform_Enum_name[int32(m.DevicePlatformId)],
+		},
+		DeviceId:        m.DeviceId,
+		ClientIp:        clientIp,
+		AuthProviderId:  m.AuthProviderId,
+		CreatedAt:       m.CreatedAt.ToTime().Unix(),
+		ExpiredAt:       m.ExpiredAt.Unix(),
+		IsCurrent:       m.Xid == currentXid,
+		IsImpersonation: m.Actor != nil,
+	}
+}
+
+// findPasswordCredentials returns PASSWORD credentials of user, one for every identifier
+func (s *Service) findPasswordCredentials(userId int64) ([]*model.UserCredential, error) {
+	credentials, err := s.repo.FindCredentialsByUserId(userId)
+	if err != nil {
+		s.log.Error("Failed to FindCredentialsByUserId", logkOption.Error(err))
+		return nil, errk.Trace(err)
+	}
+
+	var result []*model.UserCredential
+	for _, credential := range credentials {
+		if credential.AuthProviderId == dto.AuthProvider_PASSWORD {
+			result = append(result, credential)
+		}
+	}
+
+	return result, nil
+}
+
+// generateSecretToken generates a random URL-safe token sent to user, only its hash is stored
+func generateSecretToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", errk.Trace(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashSecretToken returns the hash of a secret token used as its storage key
+func hashSecretToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// generateNumericCode generates a random numeric code of the given digits, e.g. a one-time code sent by SMS
+func generateNumericCode(digits int) (string, error) {
+	max := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(digits)), nil)
+	n, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		return "", errk.Trace(err)
+	}
+	return fmt.Sprintf("%0*d", digits, n), nil
+}
@@ -0,0 +1,253 @@
+package service
+
+import (
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-konsultin/errk"
+	logkOption "github.com/go-konsultin/logk/option"
+	"github.com/konsultin/project-goes-here/dto"
+	specErr "github.com/konsultin/project-goes-here/internal/errors"
+	"github.com/konsultin/project-goes-here/internal/svc-core/model"
+	"github.com/konsultin/project-goes-here/internal/svc-core/pkg/sender"
+)
+
+const verificationCodeDigits = 6
+
+// RequestVerification sends a verification code to an email or phone identifier of a PASSWORD credential.
+// It succeeds even if identifier is not registered, and requests are limited per identifier whether it is registered
+// or not, so it can not be used to discover accounts
+// Requires anonymous session bearer token for authentication
+func (s *Service) RequestVerification(payload *dto.RequestVerification_Payload) error {
+	// Verify anonymous session token first
+	if err := s.verifyAnonymousSession(); err != nil {
+		return err
+	}
+
+	identifier := normalizeIdentifier(payload.Identifier)
+
+	// Limit requests before looking up credential
+	interval := time.Duration(s.config.VerificationResendInterval) * time.Second
+	allowed, err := s.repo.InsertVerificationCooldown(identifier, interval)
+	if err != nil {
+		s.log.Error("Failed to InsertVerificationCooldown", logkOption.Error(err))
+		return errk.Trace(err)
+	}
+	if !allowed {
+		s.log.Warnf("Verification requested again too soon for identifier: %s", identifier)
+		return specErr.TooManyVerificationRequests
+	}
+
+	count, err := s.repo.IncrVerificationRequest(identifier, time.Duration(s.config.VerificationRequestWindow)*time.Second)
+	if err != nil {
+		s.log.Error("Failed to IncrVerificationRequest", logkOption.Error(err))
+		return errk.Trace(err)
+	}
+	if count > int64(s.config.VerificationMaxRequests) {
+		s.log.Warnf("Too many verification requests for identifier: %s", identifier)
+		return specErr.TooManyVerificationRequests
+	}
+
+	credential, err := s.repo.FindCredentialByKey(dto.AuthProvider_PASSWORD, identifier)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			s.log.Warnf("Verification requested for unknown identifier: %s", identifier)
+			return nil
+		}
+		s.log.Error("Failed to find credential", logkOption.Error(err))
+		return errk.Trace(err)
+	}
+
+	if credential.IsVerified {
+		s.log.Warnf("Verification requested for verified credential. CredentialId=%d", credential.Id)
+		return nil
+	}
+
+	user, err := s.getUserById(credential.UserId)
+	if err != nil {
+		return errk.Trace(err)
+	}
+
+	// Username can not receive a code
+	if identifier != user.Email.String && identifier != user.Phone.String {
+		s.log.Warnf("Verification requested for identifier that is not an email or phone. CredentialId=%d", credential.Id)
+		return nil
+	}
+
+	return s.sendVerificationCode(credential)
+}
+
+// ConfirmVerification marks the PASSWORD credential of the identifier as verified with the code sent to it.
+// A PENDING user becomes ACTIVE once verified
+// Requires anonymous session bearer token for authentication
+func (s *Service) ConfirmVerification(payload *dto.ConfirmVerification_Payload) error {
+	// Verify anonymous session token first
+	if err := s.verifyAnonymousSession(); err != nil {
+		return err
+	}
+
+	identifier := normalizeIdentifier(payload.Identifier)
+
+	credential, err := s.repo.FindCredentialByKey(dto.AuthProvider_PASSWORD, identifier)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			s.log.Warnf("Verification confirmed for unknown identifier: %s", identifier)
+			return specErr.InvalidVerificationCode
+		}
+		s.log.Error("Failed to find credential", logkOption.Error(err))
+		return errk.Trace(err)
+	}
+
+	verification, err := s.repo.FindCredentialVerification(credential.Id)
+	if err != nil {
+		s.log.Error("Failed to FindCredentialVerification", logkOption.Error(err))
+		return errk.Trace(err)
+	}
+	if verification == nil {
+		s.log.Warnf("No pending verification. CredentialId=%d", credential.Id)
+		return specErr.InvalidVerificationCode
+	}
+
+	// Count attempt before comparing, so concurrent attempts can not go over the limit
+	lifetime := time.Duration(s.config.VerificationCodeLifetime) * time.Second
+	attempts, err := s.repo.IncrVerificationAttempt(credential.Id, lifetime)
+	if err != nil {
+		s.log.Error("Failed to IncrVerificationAttempt", logkOption.Error(err))
+		return errk.Trace(err)
+	}
+	if attempts > int64(s.config.VerificationMaxAttempts) {
+		s.log.Warnf("Too many verification attempts. CredentialId=%d", credential.Id)
+		if err = s.discardVerification(credential.Id); err != nil {
+			return errk.Trace(err)
+		}
+		return specErr.InvalidVerificationCode
+	}
+
+	// Compare code, the pending verification is dropped with the last allowed attempt
+	codeHash := hashSecretToken(fmt.Sprintf("%d:%s", credential.Id, payload.Code))
+	if subtle.ConstantTimeCompare([]byte(codeHash), []byte(verification.CodeHash)) != 1 {
+		if attempts == int64(s.config.VerificationMaxAttempts) {
+			s.log.Warnf("Too many invalid verification attempts. CredentialId=%d", credential.Id)
+			if err = s.discardVerification(credential.Id); err != nil {
+				return errk.Trace(err)
+			}
+		}
+		return specErr.InvalidVerificationCode
+	}
+
+	if err = s.discardVerification(credential.Id); err != nil {
+		return errk.Trace(err)
+	}
+
+	err = s.repo.UpdateCredentialVerified(credential.Id)
+	if err != nil {
+		s.log.Error("Failed to UpdateCredentialVerified", logkOption.Error(err))
+		return errk.Trace(err)
+	}
+
+	// Activate user waiting for verification
+	user, err := s.getUserById(credential.UserId)
+	if err != nil {
+		return errk.Trace(err)
+	}
+	if user.StatusId == dto.ControlStatus_PENDING {
+		err = s.repo.UpdateUserStatus(user.Id, dto.ControlStatus_ACTIVE, s.subject)
+		if err != nil {
+			s.log.Error("Failed to UpdateUserStatus", logkOption.Error(err))
+			return errk.Trace(err)
+		}
+		s.log.Infof("User activated by verification. UserId=%d", user.Id)
+	}
+
+	s.log.Infof("Credential verified. UserId=%d CredentialId=%d", user.Id, credential.Id)
+
+	return nil
+}
+
+// sendVerificationCode issues a verification code for the credential and sends it to its identifier.
+// Invalid attempts of the pending code are carried over, so resending does not reset them
+func (s *Service) sendVerificationCode(credential *model.UserCredential) error {
+	code, err := generateNumericCode(verificationCodeDigits)
+	if err != nil {
+		return errk.Trace(err)
+	}
+
+	lifetime := time.Duration(s.config.VerificationCodeLifetime) * time.Second
+	err = s.repo.InsertCredentialVerification(&model.CredentialVerification{
+		CredentialId: credential.Id,
+		UserId:       credential.UserId,
+		CodeHash:     hashSecretToken(fmt.Sprintf("%d:%s", credential.Id, code)),
+		CreatedAt:    time.Now(),
+	}, lifetime)
+	if err != nil {
+		s.log.Error("Failed to InsertCredentialVerification", logkOption.Error(err))
+		return errk.Trace(err)
+	}
+
+	err = s.repo.ExpireVerificationAttempt(credential.Id, lifetime)
+	if err != nil {
+		s.log.Error("Failed to ExpireVerificationAttempt", logkOption.Error(err))
+		return errk.Trace(err)
+	}
+
+	err = s.sender.Send(s.ctx, &sender.Message{
+		Channel:   sender.ChannelOf(credential.CredentialKey),
+		Recipient: credential.CredentialKey,
+		Subject:   "Verify your account",
+		Body:      fmt.Sprintf("Your verification code is %s. It expires in %s.", code, lifetime),
+	})
+	if err != nil {
+		s.log.Error("Failed to send verification code", logkOption.Error(err))
+		return errk.Trace(err)
+	}
+
+	s.log.Infof("Verification code sent. CredentialId=%d", credential.Id)
+
+	return nil
+}
+
+// discardVerification drops pending verification of a credential with its attempts
+func (s *Service) discardVerification(credentialId int64) error {
+	err := s.repo.DeleteCredentialVerification(credentialId)
+	if err != nil {
+		s.log.Error("Failed to DeleteCredentialVerification", logkOption.Error(err))
+		return errk.Trace(err)
+	}
+
+	err = s.repo.DeleteVerificationAttempt(credentialId)
+	if err != nil {
+		s.log.Error("Failed to DeleteVerificationAttempt", logkOption.Error(err))
+		return errk.Trace(err)
+	}
+
+	return nil
+}
+
+// isUserVerified checks if any email or phone PASSWORD credential of the user has been verified
+func (s *Service) isUserVerified(userId int64) (bool, error) {
+	credentials, err := s.findPasswordCredentials(userId)
+	if err != nil {
+		return false, errk.Trace(err)
+	}
+
+	for _, credential := range credentials {
+		if credential.IsVerified {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// normalizeIdentifier trims identifier and lowercases email
+func normalizeIdentifier(identifier string) string {
+	identifier = strings.TrimSpace(identifier)
+	if strings.Contains(identifier, "@") {
+		identifier = strings.ToLower(identifier)
+	}
+	return identifier
+}
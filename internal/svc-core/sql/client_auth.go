@@ -8,6 +8,10 @@ import (
 
 type ClientAuth struct {
 	FindByClientId *sqlx.Stmt
+	FindAll        *sqlx.Stmt
+	Insert         *sqlx.Stmt
+	Update         *sqlx.Stmt
+	UpdateOptions  *sqlx.Stmt
 }
 
 func NewClientAuth(db *sqlk.DatabaseContext) *ClientAuth {
@@ -16,5 +20,24 @@ func NewClientAuth(db *sqlk.DatabaseContext) *ClientAuth {
 			From(ClientAuthSchema).
 			Where(query.Equal(query.Column("clientId"))).
 			Build()),
+		FindAll: db.MustPrepareRebind(`
+			SELECT * FROM "ClientAuth"
+			ORDER BY "id"
+		`),
+		Insert: db.MustPrepareRebind(`
+			INSERT INTO "ClientAuth" ("name", "clientId", "clientTypeId", "options", "statusId", "createdAt", "updatedAt", "modifiedBy", "version", "metadata")
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			RETURNING "id"
+		`),
+		Update: db.MustPrepareRebind(`
+			UPDATE "ClientAuth"
+			SET "name" = ?, "options" = ?, "statusId" = ?, "modifiedBy" = ?, "updatedAt" = NOW(), "version" = "version" + 1
+			WHERE "id" = ?
+		`),
+		UpdateOptions: db.MustPrepareRebind(`
+			UPDATE "ClientAuth"
+			SET "options" = ?, "updatedAt" = NOW(), "version" = "version" + 1
+			WHERE "id" = ? AND "version" = ?
+		`),
 	}
 }
@@ -3,17 +3,23 @@ package coreSql
 import "github.com/go-konsultin/sqlk"
 
 type Statements struct {
-	User           *User
-	UserCredential *UserCredentialSql
-	ClientAuth     *ClientAuth
-	Role           *Role
+	User               *User
+	UserCredential     *UserCredentialSql
+	ClientAuth         *ClientAuth
+	Role               *Role
+	Privilege          *Privilege
+	UserRecoveryCode   *UserRecoveryCode
+	ImpersonationAudit *ImpersonationAudit
 }
 
 func New(db *sqlk.DatabaseContext) *Statements {
 	return &Statements{
-		User:           NewUser(db),
-		UserCredential: NewUserCredential(db),
-		ClientAuth:     NewClientAuth(db),
-		Role:           NewRole(db),
+		User:               NewUser(db),
+		UserCredential:     NewUserCredential(db),
+		ClientAuth:         NewClientAuth(db),
+		Role:               NewRole(db),
+		Privilege:          NewPrivilege(db),
+		UserRecoveryCode:   NewUserRecoveryCode(db),
+		ImpersonationAudit: NewImpersonationAudit(db),
 	}
 }
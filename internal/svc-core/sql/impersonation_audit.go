@@ -0,0 +1,29 @@
+package coreSql
+
+import (
+	"github.com/go-konsultin/sqlk"
+	"github.com/go-konsultin/sqlk/pq/query"
+	"github.com/jmoiron/sqlx"
+)
+
+type ImpersonationAudit struct {
+	Insert *sqlx.NamedStmt
+}
+
+func NewImpersonationAudit(db *sqlk.DatabaseContext) *ImpersonationAudit {
+	return &ImpersonationAudit{
+		Insert: db.MustPrepareNamed(
+			query.Insert(ImpersonationAuditSchema,
+				"sessionXid",
+				"event",
+				"adminId",
+				"userXid",
+				"reason",
+				"clientIp",
+				"actor",
+				"metadata",
+				"createdAt",
+			).Build(),
+		),
+	}
+}
@@ -0,0 +1,19 @@
+package coreSql
+
+import (
+	"github.com/go-konsultin/sqlk"
+	"github.com/go-konsultin/sqlk/pq/query"
+	"github.com/jmoiron/sqlx"
+)
+
+type Privilege struct {
+	FindAll *sqlx.Stmt
+}
+
+func NewPrivilege(db *sqlk.DatabaseContext) *Privilege {
+	return &Privilege{
+		FindAll: db.MustPrepareRebind(query.Select(query.Column("*")).
+			From(PrivilegeSchema).
+			Build()),
+	}
+}
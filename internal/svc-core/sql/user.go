@@ -11,6 +11,7 @@ type User struct {
 	GetUserById      *sqlx.Stmt
 	FindByIdentifier *sqlx.Stmt
 	Insert           *sqlx.NamedStmt
+	UpdateStatus     *sqlx.Stmt
 }
 
 func NewUser(db *sqlk.DatabaseContext) *User {
@@ -64,5 +65,10 @@ func NewUser(db *sqlk.DatabaseContext) *User {
 				"metadata",
 			).Build(),
 		),
+		UpdateStatus: db.MustPrepareRebind(`
+			UPDATE "User"
+			SET "statusId" = ?, "modifiedBy" = ?, "updatedAt" = NOW(), "version" = "version" + 1
+			WHERE "id" = ?
+		`),
 	}
 }
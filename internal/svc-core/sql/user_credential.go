@@ -11,6 +11,8 @@ type UserCredentialSql struct {
 	FindByUserId         *sqlx.Stmt
 	Insert               *sqlx.NamedStmt
 	UpdateSecret         *sqlx.Stmt
+	UpdateVerified       *sqlx.Stmt
+	Delete               *sqlx.Stmt
 }
 
 func NewUserCredential(db *sqlk.DatabaseContext) *UserCredentialSql {
@@ -53,5 +55,14 @@ func NewUserCredential(db *sqlk.DatabaseContext) *UserCredentialSql {
 			SET "credentialSecret" = ?, "updatedAt" = NOW()
 			WHERE "id" = ?
 		`),
+		UpdateVerified: db.MustPrepareRebind(`
+			UPDATE "UserCredential"
+			SET "isVerified" = true, "verifiedAt" = NOW(), "updatedAt" = NOW()
+			WHERE "id" = ?
+		`),
+		Delete: db.MustPrepareRebind(`
+			DELETE FROM "UserCredential"
+			WHERE "id" = ?
+		`),
 	}
 }
@@ -0,0 +1,34 @@
+package coreSql
+
+import (
+	"github.com/go-konsultin/sqlk"
+	"github.com/go-konsultin/sqlk/pq/query"
+	"github.com/jmoiron/sqlx"
+)
+
+type UserRecoveryCode struct {
+	Insert       *sqlx.NamedStmt
+	Use          *sqlx.Stmt
+	DeleteByUser *sqlx.Stmt
+}
+
+func NewUserRecoveryCode(db *sqlk.DatabaseContext) *UserRecoveryCode {
+	return &UserRecoveryCode{
+		Insert: db.MustPrepareNamed(
+			query.Insert(UserRecoveryCodeSchema,
+				"userId",
+				"codeHash",
+				"createdAt",
+			).Build(),
+		),
+		Use: db.MustPrepareRebind(`
+			UPDATE "UserRecoveryCode"
+			SET "usedAt" = NOW()
+			WHERE "userId" = ? AND "codeHash" = ? AND "usedAt" IS NULL
+		`),
+		DeleteByUser: db.MustPrepareRebind(`
+			DELETE FROM "UserRecoveryCode"
+			WHERE "userId" = ?
+		`),
+	}
+}
@@ -0,0 +1,185 @@
+package svcCore
+
+import (
+	"github.com/konsultin/project-goes-here/dto"
+	httpkPkg "github.com/konsultin/project-goes-here/internal/svc-core/pkg/httpk"
+	f "github.com/valyala/fasthttp"
+)
+
+// HandleEnrollTotp handles enrollment of a TOTP secret for current user
+// @Summary      Enroll TOTP
+// @Description  Create a TOTP secret and its otpauth URI. Two-factor authentication is enabled once confirmed with a code
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Success      200  {object}  dto.Response[dto.EnrollTotp_Result]
+// @Failure      401  {object}  dto.Response[dto.Empty] "Unauthorized"
+// @Failure      409  {object}  dto.Response[dto.Empty] "Already Enabled"
+// @Failure      500  {object}  dto.Response[dto.Empty] "Internal Error"
+// @Router       /v1/users/me/2fa/totp [post]
+func (s *Server) HandleEnrollTotp(ctx *f.RequestCtx) (*dto.EnrollTotp_Result, error) {
+	// Init Service
+	svc, err := s.NewService(ctx)
+	if err != nil {
+		s.log.Errorf("Failed to create service: %v", err)
+		return nil, err
+	}
+	defer svc.Close()
+
+	data, err := svc.EnrollTotp()
+	if err != nil {
+		return nil, s.wrapError(ctx, err)
+	}
+
+	return data, nil
+}
+
+// HandleConfirmTotp handles enabling two-factor authentication with a code of the enrolled TOTP secret
+// @Summary      Confirm TOTP
+// @Description  Enable two-factor authentication with a TOTP code and return recovery codes. Recovery codes are only shown once
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        request body dto.TwoFactorCode_Payload true "Two-Factor Code Payload"
+// @Success      200  {object}  dto.Response[dto.RecoveryCodes_Result]
+// @Failure      400  {object}  dto.Response[dto.Empty] "Invalid Code"
+// @Failure      401  {object}  dto.Response[dto.Empty] "Unauthorized"
+// @Failure      409  {object}  dto.Response[dto.Empty] "Already Enabled"
+// @Failure      422  {object}  dto.Response[dto.Empty] "Invalid Payload"
+// @Failure      500  {object}  dto.Response[dto.Empty] "Internal Error"
+// @Router       /v1/users/me/2fa/totp/confirm [post]
+func (s *Server) HandleConfirmTotp(ctx *f.RequestCtx) (*dto.RecoveryCodes_Result, error) {
+	// Bind and validate request payload
+	payload, err := httpkPkg.BindAndValidate[dto.TwoFactorCode_Payload](ctx)
+	if err != nil {
+		return nil, s.wrapError(ctx, err)
+	}
+
+	// Init Service
+	svc, err := s.NewService(ctx)
+	if err != nil {
+		s.log.Errorf("Failed to create service: %v", err)
+		return nil, err
+	}
+	defer svc.Close()
+
+	data, err := svc.ConfirmTotp(payload)
+	if err != nil {
+		return nil, s.wrapError(ctx, err)
+	}
+
+	return data, nil
+}
+
+// HandleDisableTotp handles disabling two-factor authentication of current user
+// @Summary      Disable TOTP
+// @Description  Disable two-factor authentication with a TOTP or recovery code, deleting the secret and recovery codes
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        request body dto.TwoFactorCode_Payload true "Two-Factor Code Payload"
+// @Success      200  {object}  dto.Response[dto.Empty]
+// @Failure      400  {object}  dto.Response[dto.Empty] "Invalid Code Or Not Enabled"
+// @Failure      401  {object}  dto.Response[dto.Empty] "Unauthorized"
+// @Failure      422  {object}  dto.Response[dto.Empty] "Invalid Payload"
+// @Failure      500  {object}  dto.Response[dto.Empty] "Internal Error"
+// @Router       /v1/users/me/2fa/totp [delete]
+func (s *Server) HandleDisableTotp(ctx *f.RequestCtx) (*dto.Empty, error) {
+	// Bind and validate request payload
+	payload, err := httpkPkg.BindAndValidate[dto.TwoFactorCode_Payload](ctx)
+	if err != nil {
+		return nil, s.wrapError(ctx, err)
+	}
+
+	// Init Service
+	svc, err := s.NewService(ctx)
+	if err != nil {
+		s.log.Errorf("Failed to create service: %v", err)
+		return nil, err
+	}
+	defer svc.Close()
+
+	err = svc.DisableTotp(payload)
+	if err != nil {
+		return nil, s.wrapError(ctx, err)
+	}
+
+	return &dto.Empty{}, nil
+}
+
+// HandleRegenerateRecoveryCodes handles replacing recovery codes of current user
+// @Summary      Regenerate recovery codes
+// @Description  Replace recovery codes with a TOTP or recovery code, the previous codes stop working
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        request body dto.TwoFactorCode_Payload true "Two-Factor Code Payload"
+// @Success      200  {object}  dto.Response[dto.RecoveryCodes_Result]
+// @Failure      400  {object}  dto.Response[dto.Empty] "Invalid Code Or Not Enabled"
+// @Failure      401  {object}  dto.Response[dto.Empty] "Unauthorized"
+// @Failure      422  {object}  dto.Response[dto.Empty] "Invalid Payload"
+// @Failure      500  {object}  dto.Response[dto.Empty] "Internal Error"
+// @Router       /v1/users/me/2fa/recovery-codes [post]
+func (s *Server) HandleRegenerateRecoveryCodes(ctx *f.RequestCtx) (*dto.RecoveryCodes_Result, error) {
+	// Bind and validate request payload
+	payload, err := httpkPkg.BindAndValidate[dto.TwoFactorCode_Payload](ctx)
+	if err != nil {
+		return nil, s.wrapError(ctx, err)
+	}
+
+	// Init Service
+	svc, err := s.NewService(ctx)
+	if err != nil {
+		s.log.Errorf("Failed to create service: %v", err)
+		return nil, err
+	}
+	defer svc.Close()
+
+	data, err := svc.RegenerateRecoveryCodes(payload)
+	if err != nil {
+		return nil, s.wrapError(ctx, err)
+	}
+
+	return data, nil
+}
+
+// HandleLoginTwoFactor handles exchanging a two-factor challenge for the user session
+// @Summary      Login with second factor
+// @Description  Exchange the challenge token returned by password login and a TOTP or recovery code for the user session
+// @Tags         sessions
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        request body dto.LoginTwoFactor_Payload true "Login Two-Factor Payload"
+// @Success      200  {object}  dto.Response[dto.CreateUserSession_Result_Data]
+// @Failure      400  {object}  dto.Response[dto.Empty] "Invalid Code"
+// @Failure      401  {object}  dto.Response[dto.Empty] "Unauthorized Or Invalid Challenge"
+// @Failure      422  {object}  dto.Response[dto.Empty] "Invalid Payload"
+// @Failure      500  {object}  dto.Response[dto.Empty] "Internal Error"
+// @Router       /v1/users/sessions/2fa [post]
+func (s *Server) HandleLoginTwoFactor(ctx *f.RequestCtx) (*dto.CreateUserSession_Result_Data, error) {
+	// Bind and validate request payload
+	payload, err := httpkPkg.BindAndValidate[dto.LoginTwoFactor_Payload](ctx)
+	if err != nil {
+		return nil, s.wrapError(ctx, err)
+	}
+
+	// Init Service
+	svc, err := s.NewService(ctx)
+	if err != nil {
+		s.log.Errorf("Failed to create service: %v", err)
+		return nil, err
+	}
+	defer svc.Close()
+
+	data, err := svc.LoginTwoFactor(payload)
+	if err != nil {
+		return nil, s.wrapError(ctx, err)
+	}
+
+	return data, nil
+}
@@ -56,7 +56,7 @@ func (s *Server) HandleUserRefreshToken(ctx *f.RequestCtx) (*dto.CreateUserSessi
 
 // HandleLoginPassword handles login with email/phone/username + password
 // @Summary      Login with Password
-// @Description  Authenticate user using identifier (email/phone/username) and password
+// @Description  Authenticate user using identifier (email/phone/username) and password. If two-factor authentication is enabled, only twoFactorChallenge is returned, to be exchanged at /v1/users/sessions/2fa
 // @Tags         sessions
 // @Accept       json
 // @Produce      json
@@ -90,8 +90,19 @@ func (s *Server) HandleLoginPassword(ctx *f.RequestCtx) (*dto.CreateUserSession_
 	return data, nil
 }
 
-// HandleLoginGoogle handles login with Google OAuth
-func (s *Server) HandleLoginGoogle(ctx *f.RequestCtx) (*dto.CreateUserSession_Result_Data, error) {
+// HandleLoginOAuth handles login with any registered OAuth identity provider
+// @Summary      Login with OAuth
+// @Description  Authenticate user using ID token (or access token) issued by the identity provider selected in payload
+// @Tags         sessions
+// @Accept       json
+// @Produce      json
+// @Param        request body dto.LoginOAuth_Payload true "Login OAuth Payload"
+// @Success      200  {object}  dto.Response[dto.CreateUserSession_Result_Data]
+// @Failure      400  {object}  dto.Response[dto.Empty] "Unsupported Provider"
+// @Failure      401  {object}  dto.Response[dto.Empty] "Unauthorized"
+// @Failure      500  {object}  dto.Response[dto.Empty] "Internal Error"
+// @Router       /v1/users/sessions/oauth [post]
+func (s *Server) HandleLoginOAuth(ctx *f.RequestCtx) (*dto.CreateUserSession_Result_Data, error) {
 	// Bind and validate request payload
 	payload, err := httpkPkg.BindAndValidate[dto.LoginOAuth_Payload](ctx)
 	if err != nil {
@@ -106,11 +117,489 @@ func (s *Server) HandleLoginGoogle(ctx *f.RequestCtx) (*dto.CreateUserSession_Re
 	}
 	defer svc.Close()
 
-	// Login with Google
-	data, err := svc.LoginWithGoogle(payload)
+	// Login with OAuth provider
+	data, err := svc.LoginWithOAuth(payload)
 	if err != nil {
 		return nil, s.wrapError(ctx, err)
 	}
 
 	return data, nil
 }
+
+// HandleRegisterUser handles self-service user registration with password
+// @Summary      Register User
+// @Description  Register a new user with email/phone/username and password, then create a user session
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Param        request body dto.RegisterUser_Payload true "Register User Payload"
+// @Success      200  {object}  dto.Response[dto.CreateUserSession_Result_Data]
+// @Failure      401  {object}  dto.Response[dto.Empty] "Unauthorized"
+// @Failure      409  {object}  dto.Response[dto.Empty] "Identifier Already Registered"
+// @Failure      422  {object}  dto.Response[dto.Empty] "Invalid Payload"
+// @Failure      500  {object}  dto.Response[dto.Empty] "Internal Error"
+// @Router       /v1/users [post]
+func (s *Server) HandleRegisterUser(ctx *f.RequestCtx) (*dto.CreateUserSession_Result_Data, error) {
+	// Bind and validate request payload
+	payload, err := httpkPkg.BindAndValidate[dto.RegisterUser_Payload](ctx)
+	if err != nil {
+		return nil, s.wrapError(ctx, err)
+	}
+
+	// Init Service
+	svc, err := s.NewService(ctx)
+	if err != nil {
+		s.log.Errorf("Failed to create service: %v", err)
+		return nil, err
+	}
+	defer svc.Close()
+
+	// Register user
+	data, err := svc.RegisterUser(payload)
+	if err != nil {
+		return nil, s.wrapError(ctx, err)
+	}
+
+	return data, nil
+}
+
+// HandleLogout handles logout of current user session
+// @Summary      Logout
+// @Description  Delete the session of current bearer token
+// @Tags         sessions
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Success      200  {object}  dto.Response[dto.Empty]
+// @Failure      401  {object}  dto.Response[dto.Empty] "Unauthorized"
+// @Failure      500  {object}  dto.Response[dto.Empty] "Internal Error"
+// @Router       /v1/users/sessions [delete]
+func (s *Server) HandleLogout(ctx *f.RequestCtx) (*dto.Empty, error) {
+	// Init Service
+	svc, err := s.NewService(ctx)
+	if err != nil {
+		s.log.Errorf("Failed to create service: %v", err)
+		return nil, err
+	}
+	defer svc.Close()
+
+	err = svc.Logout()
+	if err != nil {
+		return nil, s.wrapError(ctx, err)
+	}
+
+	return &dto.Empty{}, nil
+}
+
+// HandleLogoutAll handles logout of every session of current user
+// @Summary      Logout from all devices
+// @Description  Delete every session of current user
+// @Tags         sessions
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Success      200  {object}  dto.Response[dto.Empty]
+// @Failure      401  {object}  dto.Response[dto.Empty] "Unauthorized"
+// @Failure      500  {object}  dto.Response[dto.Empty] "Internal Error"
+// @Router       /v1/users/sessions/all [delete]
+func (s *Server) HandleLogoutAll(ctx *f.RequestCtx) (*dto.Empty, error) {
+	// Init Service
+	svc, err := s.NewService(ctx)
+	if err != nil {
+		s.log.Errorf("Failed to create service: %v", err)
+		return nil, err
+	}
+	defer svc.Close()
+
+	err = svc.LogoutAll()
+	if err != nil {
+		return nil, s.wrapError(ctx, err)
+	}
+
+	return &dto.Empty{}, nil
+}
+
+// HandleListMySessions handles listing of active sessions of current user
+// @Summary      List my sessions
+// @Description  List active sessions of current user with their device, client IP and creation time
+// @Tags         sessions
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Success      200  {object}  dto.Response[dto.ListActiveSession_Result]
+// @Failure      401  {object}  dto.Response[dto.Empty] "Unauthorized"
+// @Failure      500  {object}  dto.Response[dto.Empty] "Internal Error"
+// @Router       /v1/users/me/sessions [get]
+func (s *Server) HandleListMySessions(ctx *f.RequestCtx) (*dto.ListActiveSession_Result, error) {
+	// Init Service
+	svc, err := s.NewService(ctx)
+	if err != nil {
+		s.log.Errorf("Failed to create service: %v", err)
+		return nil, err
+	}
+	defer svc.Close()
+
+	result, err := svc.ListMySessions()
+	if err != nil {
+		return nil, s.wrapError(ctx, err)
+	}
+
+	return result, nil
+}
+
+// HandleRevokeMySession handles revocation of a single session of current user
+// @Summary      Revoke my session
+// @Description  Delete a session of current user, signing out the device
+// @Tags         sessions
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        xid  path  string  true  "Session Xid"
+// @Success      200  {object}  dto.Response[dto.Empty]
+// @Failure      401  {object}  dto.Response[dto.Empty] "Unauthorized"
+// @Failure      404  {object}  dto.Response[dto.Empty] "Session Not Found"
+// @Failure      500  {object}  dto.Response[dto.Empty] "Internal Error"
+// @Router       /v1/users/me/sessions/{xid} [delete]
+func (s *Server) HandleRevokeMySession(ctx *f.RequestCtx) (*dto.Empty, error) {
+	xid, _ := ctx.UserValue("xid").(string)
+
+	// Init Service
+	svc, err := s.NewService(ctx)
+	if err != nil {
+		s.log.Errorf("Failed to create service: %v", err)
+		return nil, err
+	}
+	defer svc.Close()
+
+	err = svc.RevokeMySession(xid)
+	if err != nil {
+		return nil, s.wrapError(ctx, err)
+	}
+
+	return &dto.Empty{}, nil
+}
+
+// HandleChangePassword handles changing password of current user
+// @Summary      Change password
+// @Description  Set a new password after checking the current one, then sign out every other device
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        request body dto.ChangePassword_Payload true "Change Password Payload"
+// @Success      200  {object}  dto.Response[dto.Empty]
+// @Failure      400  {object}  dto.Response[dto.Empty] "Incorrect Current Password"
+// @Failure      401  {object}  dto.Response[dto.Empty] "Unauthorized"
+// @Failure      403  {object}  dto.Response[dto.Empty] "Password Not Set"
+// @Failure      422  {object}  dto.Response[dto.Empty] "Invalid Payload"
+// @Failure      500  {object}  dto.Response[dto.Empty] "Internal Error"
+// @Router       /v1/users/me/password [put]
+func (s *Server) HandleChangePassword(ctx *f.RequestCtx) (*dto.Empty, error) {
+	// Bind and validate request payload
+	payload, err := httpkPkg.BindAndValidate[dto.ChangePassword_Payload](ctx)
+	if err != nil {
+		return nil, s.wrapError(ctx, err)
+	}
+
+	// Init Service
+	svc, err := s.NewService(ctx)
+	if err != nil {
+		s.log.Errorf("Failed to create service: %v", err)
+		return nil, err
+	}
+	defer svc.Close()
+
+	err = svc.ChangePassword(payload)
+	if err != nil {
+		return nil, s.wrapError(ctx, err)
+	}
+
+	return &dto.Empty{}, nil
+}
+
+// HandleRequestPasswordReset handles request of a password reset token
+// @Summary      Request password reset
+// @Description  Send a single-use password reset token to the email or phone of the user. Always succeeds to not disclose registered identifiers
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        request body dto.RequestPasswordReset_Payload true "Request Password Reset Payload"
+// @Success      200  {object}  dto.Response[dto.Empty]
+// @Failure      401  {object}  dto.Response[dto.Empty] "Unauthorized"
+// @Failure      422  {object}  dto.Response[dto.Empty] "Invalid Payload"
+// @Failure      500  {object}  dto.Response[dto.Empty] "Internal Error"
+// @Router       /v1/users/password/reset [post]
+func (s *Server) HandleRequestPasswordReset(ctx *f.RequestCtx) (*dto.Empty, error) {
+	// Bind and validate request payload
+	payload, err := httpkPkg.BindAndValidate[dto.RequestPasswordReset_Payload](ctx)
+	if err != nil {
+		return nil, s.wrapError(ctx, err)
+	}
+
+	// Init Service
+	svc, err := s.NewService(ctx)
+	if err != nil {
+		s.log.Errorf("Failed to create service: %v", err)
+		return nil, err
+	}
+	defer svc.Close()
+
+	err = svc.RequestPasswordReset(payload)
+	if err != nil {
+		return nil, s.wrapError(ctx, err)
+	}
+
+	return &dto.Empty{}, nil
+}
+
+// HandleConfirmPasswordReset handles setting a new password with a password reset token
+// @Summary      Confirm password reset
+// @Description  Set a new password with the password reset token, then sign out every device
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        request body dto.ConfirmPasswordReset_Payload true "Confirm Password Reset Payload"
+// @Success      200  {object}  dto.Response[dto.Empty]
+// @Failure      400  {object}  dto.Response[dto.Empty] "Invalid Or Expired Token"
+// @Failure      401  {object}  dto.Response[dto.Empty] "Unauthorized"
+// @Failure      422  {object}  dto.Response[dto.Empty] "Invalid Payload"
+// @Failure      500  {object}  dto.Response[dto.Empty] "Internal Error"
+// @Router       /v1/users/password/reset/confirm [post]
+func (s *Server) HandleConfirmPasswordReset(ctx *f.RequestCtx) (*dto.Empty, error) {
+	// Bind and validate request payload
+	payload, err := httpkPkg.BindAndValidate[dto.ConfirmPasswordReset_Payload](ctx)
+	if err != nil {
+		return nil, s.wrapError(ctx, err)
+	}
+
+	// Init Service
+	svc, err := s.NewService(ctx)
+	if err != nil {
+		s.log.Errorf("Failed to create service: %v", err)
+		return nil, err
+	}
+	defer svc.Close()
+
+	err = svc.ConfirmPasswordReset(payload)
+	if err != nil {
+		return nil, s.wrapError(ctx, err)
+	}
+
+	return &dto.Empty{}, nil
+}
+
+// HandleRequestVerification handles request of an email or phone verification code
+// @Summary      Request verification code
+// @Description  Send a verification code to an unverified email or phone. Always succeeds to not disclose registered identifiers
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        request body dto.RequestVerification_Payload true "Request Verification Payload"
+// @Success      200  {object}  dto.Response[dto.Empty]
+// @Failure      401  {object}  dto.Response[dto.Empty] "Unauthorized"
+// @Failure      422  {object}  dto.Response[dto.Empty] "Invalid Payload"
+// @Failure      500  {object}  dto.Response[dto.Empty] "Internal Error"
+// @Router       /v1/users/verification [post]
+func (s *Server) HandleRequestVerification(ctx *f.RequestCtx) (*dto.Empty, error) {
+	// Bind and validate request payload
+	payload, err := httpkPkg.BindAndValidate[dto.RequestVerification_Payload](ctx)
+	if err != nil {
+		return nil, s.wrapError(ctx, err)
+	}
+
+	// Init Service
+	svc, err := s.NewService(ctx)
+	if err != nil {
+		s.log.Errorf("Failed to create service: %v", err)
+		return nil, err
+	}
+	defer svc.Close()
+
+	err = svc.RequestVerification(payload)
+	if err != nil {
+		return nil, s.wrapError(ctx, err)
+	}
+
+	return &dto.Empty{}, nil
+}
+
+// HandleConfirmVerification handles verifying an email or phone with a verification code
+// @Summary      Confirm verification code
+// @Description  Mark the email or phone as verified with the verification code. A pending user is activated
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        request body dto.ConfirmVerification_Payload true "Confirm Verification Payload"
+// @Success      200  {object}  dto.Response[dto.Empty]
+// @Failure      400  {object}  dto.Response[dto.Empty] "Invalid Or Expired Code"
+// @Failure      401  {object}  dto.Response[dto.Empty] "Unauthorized"
+// @Failure      422  {object}  dto.Response[dto.Empty] "Invalid Payload"
+// @Failure      500  {object}  dto.Response[dto.Empty] "Internal Error"
+// @Router       /v1/users/verification/confirm [post]
+func (s *Server) HandleConfirmVerification(ctx *f.RequestCtx) (*dto.Empty, error) {
+	// Bind and validate request payload
+	payload, err := httpkPkg.BindAndValidate[dto.ConfirmVerification_Payload](ctx)
+	if err != nil {
+		return nil, s.wrapError(ctx, err)
+	}
+
+	// Init Service
+	svc, err := s.NewService(ctx)
+	if err != nil {
+		s.log.Errorf("Failed to create service: %v", err)
+		return nil, err
+	}
+	defer svc.Close()
+
+	err = svc.ConfirmVerification(payload)
+	if err != nil {
+		return nil, s.wrapError(ctx, err)
+	}
+
+	return &dto.Empty{}, nil
+}
+
+// HandleRequestLoginOtp handles request of a one-time login code
+// @Summary      Request login code
+// @Description  Send a one-time login code to email or phone. Always succeeds unless rate limited, to not disclose registered identifiers
+// @Tags         sessions
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        request body dto.RequestLoginOtp_Payload true "Request Login Code Payload"
+// @Success      200  {object}  dto.Response[dto.RequestLoginOtp_Result]
+// @Failure      401  {object}  dto.Response[dto.Empty] "Unauthorized"
+// @Failure      422  {object}  dto.Response[dto.Empty] "Invalid Payload"
+// @Failure      429  {object}  dto.Response[dto.Empty] "Too Many Requests"
+// @Failure      500  {object}  dto.Response[dto.Empty] "Internal Error"
+// @Router       /v1/users/sessions/otp [post]
+func (s *Server) HandleRequestLoginOtp(ctx *f.RequestCtx) (*dto.RequestLoginOtp_Result, error) {
+	// Bind and validate request payload
+	payload, err := httpkPkg.BindAndValidate[dto.RequestLoginOtp_Payload](ctx)
+	if err != nil {
+		return nil, s.wrapError(ctx, err)
+	}
+
+	// Init Service
+	svc, err := s.NewService(ctx)
+	if err != nil {
+		s.log.Errorf("Failed to create service: %v", err)
+		return nil, err
+	}
+	defer svc.Close()
+
+	data, err := svc.RequestLoginOtp(payload)
+	if err != nil {
+		return nil, s.wrapError(ctx, err)
+	}
+
+	return data, nil
+}
+
+// HandleLoginOtp handles login with a one-time code sent to email or phone
+// @Summary      Login with code
+// @Description  Authenticate user using the one-time code sent to email or phone
+// @Tags         sessions
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        request body dto.LoginOtp_Payload true "Login Code Payload"
+// @Success      200  {object}  dto.Response[dto.CreateUserSession_Result_Data]
+// @Failure      401  {object}  dto.Response[dto.Empty] "Unauthorized Or Invalid Code"
+// @Failure      403  {object}  dto.Response[dto.Empty] "Inactive User"
+// @Failure      422  {object}  dto.Response[dto.Empty] "Invalid Payload"
+// @Failure      500  {object}  dto.Response[dto.Empty] "Internal Error"
+// @Router       /v1/users/sessions/otp/verify [post]
+func (s *Server) HandleLoginOtp(ctx *f.RequestCtx) (*dto.CreateUserSession_Result_Data, error) {
+	// Bind and validate request payload
+	payload, err := httpkPkg.BindAndValidate[dto.LoginOtp_Payload](ctx)
+	if err != nil {
+		return nil, s.wrapError(ctx, err)
+	}
+
+	// Init Service
+	svc, err := s.NewService(ctx)
+	if err != nil {
+		s.log.Errorf("Failed to create service: %v", err)
+		return nil, err
+	}
+	defer svc.Close()
+
+	data, err := svc.LoginWithOtp(payload)
+	if err != nil {
+		return nil, s.wrapError(ctx, err)
+	}
+
+	return data, nil
+}
+
+// HandleLinkOAuth handles linking an OAuth identity to current user
+// @Summary      Link OAuth identity
+// @Description  Attach the identity of the ID token to current user, so the user can sign in with the provider
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        request body dto.LinkOAuth_Payload true "Link OAuth Payload"
+// @Success      200  {object}  dto.Response[dto.Empty]
+// @Failure      400  {object}  dto.Response[dto.Empty] "Unsupported Provider"
+// @Failure      401  {object}  dto.Response[dto.Empty] "Unauthorized"
+// @Failure      409  {object}  dto.Response[dto.Empty] "Identity Already Linked"
+// @Failure      422  {object}  dto.Response[dto.Empty] "Invalid Payload"
+// @Failure      500  {object}  dto.Response[dto.Empty] "Internal Error"
+// @Router       /v1/users/me/identities [post]
+func (s *Server) HandleLinkOAuth(ctx *f.RequestCtx) (*dto.Empty, error) {
+	// Bind and validate request payload
+	payload, err := httpkPkg.BindAndValidate[dto.LinkOAuth_Payload](ctx)
+	if err != nil {
+		return nil, s.wrapError(ctx, err)
+	}
+
+	// Init Service
+	svc, err := s.NewService(ctx)
+	if err != nil {
+		s.log.Errorf("Failed to create service: %v", err)
+		return nil, err
+	}
+	defer svc.Close()
+
+	err = svc.LinkOAuth(payload)
+	if err != nil {
+		return nil, s.wrapError(ctx, err)
+	}
+
+	return &dto.Empty{}, nil
+}
+
+// HandleUnlinkOAuth handles removing an OAuth identity from current user
+// @Summary      Unlink OAuth identity
+// @Description  Remove the identity of the provider (google, facebook or apple) from current user. The last sign-in method can not be removed
+// @Tags         users
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        provider path string true "Provider name"
+// @Success      200  {object}  dto.Response[dto.Empty]
+// @Failure      400  {object}  dto.Response[dto.Empty] "Unsupported Provider"
+// @Failure      401  {object}  dto.Response[dto.Empty] "Unauthorized"
+// @Failure      404  {object}  dto.Response[dto.Empty] "Identity Not Linked"
+// @Failure      409  {object}  dto.Response[dto.Empty] "Last Sign-In Method"
+// @Failure      500  {object}  dto.Response[dto.Empty] "Internal Error"
+// @Router       /v1/users/me/identities/{provider} [delete]
+func (s *Server) HandleUnlinkOAuth(ctx *f.RequestCtx) (*dto.Empty, error) {
+	provider, _ := ctx.UserValue("provider").(string)
+
+	// Init Service
+	svc, err := s.NewService(ctx)
+	if err != nil {
+		s.log.Errorf("Failed to create service: %v", err)
+		return nil, err
+	}
+	defer svc.Close()
+
+	err = svc.UnlinkOAuth(provider)
+	if err != nil {
+		return nil, s.wrapError(ctx, err)
+	}
+
+	return &dto.Empty{}, nil
+}
@@ -0,0 +1,4 @@
+-- Register Facebook auth provider
+INSERT INTO "AuthProvider" ("id", "name", "description") VALUES
+    (3, 'FACEBOOK', 'Facebook Login authentication')
+ON CONFLICT ("id") DO NOTHING;
@@ -0,0 +1,4 @@
+-- Register Apple auth provider
+INSERT INTO "AuthProvider" ("id", "name", "description") VALUES
+    (4, 'APPLE', 'Sign in with Apple authentication')
+ON CONFLICT ("id") DO NOTHING;
@@ -0,0 +1 @@
+DELETE FROM "Privilege" WHERE "xid" = 'revoke_user_session';
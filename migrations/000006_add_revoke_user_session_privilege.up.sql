@@ -0,0 +1,21 @@
+-- Roles matching Role_Enum, privileges are granted by role id. Existing roles are kept
+INSERT INTO "Role" ("id", "xid", "name", "description", "roleTypeId") VALUES
+    (1, 'anonymous_admin', 'Anonymous Admin', 'Admin client before sign in', 1),
+    (2, 'anonymous_user', 'Anonymous User', 'User client before sign in', 2),
+    (3, 'admin', 'Admin', 'Signed in admin', 1),
+    (4, 'user', 'User', 'Signed in user', 2)
+ON CONFLICT DO NOTHING;
+
+SELECT setval(pg_get_serial_sequence('"Role"', 'id'), GREATEST((SELECT MAX("id") FROM "Role"), 1));
+
+-- Privilege for admins to revoke every session of a user
+INSERT INTO "Privilege" ("xid", "name", "exposed", "sort") VALUES
+    ('revoke_user_session', 'Revoke User Session', false, 0)
+ON CONFLICT ("xid") DO NOTHING;
+
+-- Grant to ADMIN role
+INSERT INTO "RolePrivilege" ("roleId", "privilegeId")
+SELECT r."id", p."id"
+FROM "Role" r, "Privilege" p
+WHERE r."id" = 3 AND p."xid" = 'revoke_user_session'
+ON CONFLICT ("roleId", "privilegeId") DO NOTHING;
@@ -0,0 +1 @@
+DELETE FROM "Privilege" WHERE "xid" = 'refresh_user_token';
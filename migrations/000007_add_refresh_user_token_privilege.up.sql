@@ -0,0 +1,4 @@
+-- Privilege required by refresh session route, granted to refresh tokens only
+INSERT INTO "Privilege" ("xid", "name", "exposed", "sort") VALUES
+    ('refresh_user_token', 'Refresh User Token', false, 0)
+ON CONFLICT ("xid") DO NOTHING;
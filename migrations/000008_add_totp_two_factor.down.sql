@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS "UserRecoveryCode";
+DELETE FROM "UserCredential" WHERE "authProviderId" = 5;
+DELETE FROM "AuthProvider" WHERE "id" = 5;
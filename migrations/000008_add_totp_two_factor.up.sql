@@ -0,0 +1,15 @@
+-- Register TOTP auth provider, credential secret is the base32 TOTP secret of the user
+INSERT INTO "AuthProvider" ("id", "name", "description") VALUES
+    (5, 'TOTP', 'Time-based one-time password second factor')
+ON CONFLICT ("id") DO NOTHING;
+
+-- Single-use recovery codes to sign in when the TOTP device is lost
+CREATE TABLE IF NOT EXISTS "UserRecoveryCode" (
+    "id" BIGSERIAL PRIMARY KEY,
+    "userId" BIGINT NOT NULL REFERENCES "User"("id") ON DELETE CASCADE,
+    "codeHash" VARCHAR(255) NOT NULL,
+    "usedAt" TIMESTAMP,
+    "createdAt" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_recovery_code_user_id ON "UserRecoveryCode"("userId");
@@ -0,0 +1 @@
+DELETE FROM "AuthProvider" WHERE "id" = 6;
@@ -0,0 +1,4 @@
+-- Register passwordless one-time code auth provider
+INSERT INTO "AuthProvider" ("id", "name", "description") VALUES
+    (6, 'OTP', 'Passwordless login with one-time code sent to email or phone')
+ON CONFLICT ("id") DO NOTHING;
@@ -0,0 +1 @@
+DELETE FROM "Privilege" WHERE "xid" = 'manage_client_auth';
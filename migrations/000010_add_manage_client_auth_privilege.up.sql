@@ -0,0 +1,11 @@
+-- Privilege for admins to manage client credentials
+INSERT INTO "Privilege" ("xid", "name", "exposed", "sort") VALUES
+    ('manage_client_auth', 'Manage Client Auth', false, 0)
+ON CONFLICT ("xid") DO NOTHING;
+
+-- Grant to ADMIN role
+INSERT INTO "RolePrivilege" ("roleId", "privilegeId")
+SELECT r."id", p."id"
+FROM "Role" r, "Privilege" p
+WHERE r."id" = 3 AND p."xid" = 'manage_client_auth'
+ON CONFLICT ("roleId", "privilegeId") DO NOTHING;
@@ -0,0 +1 @@
+DELETE FROM "Role" WHERE "id" = 5 AND "xid" = 'service';
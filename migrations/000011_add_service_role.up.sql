@@ -0,0 +1,7 @@
+-- Role of machine-to-machine clients authenticated with client_credentials grant.
+-- Privileges granted to this role become the audience of issued access tokens
+INSERT INTO "Role" ("id", "xid", "name", "description", "roleTypeId") VALUES
+    (5, 'service', 'Service', 'Backend integration using client_credentials grant', 9)
+ON CONFLICT DO NOTHING;
+
+SELECT setval(pg_get_serial_sequence('"Role"', 'id'), GREATEST((SELECT MAX("id") FROM "Role"), 1));
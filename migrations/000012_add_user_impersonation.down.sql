@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS "ImpersonationAudit";
+DELETE FROM "Privilege" WHERE "xid" = 'impersonate_user';
@@ -0,0 +1,30 @@
+-- Privilege for admins to act as a user with a short-lived impersonation session
+INSERT INTO "Privilege" ("xid", "name", "exposed", "sort") VALUES
+    ('impersonate_user', 'Impersonate User', false, 0)
+ON CONFLICT ("xid") DO NOTHING;
+
+-- Grant to ADMIN role
+INSERT INTO "RolePrivilege" ("roleId", "privilegeId")
+SELECT r."id", p."id"
+FROM "Role" r, "Privilege" p
+WHERE r."id" = 3 AND p."xid" = 'impersonate_user'
+ON CONFLICT ("roleId", "privilegeId") DO NOTHING;
+
+-- Append-only trail of impersonation sessions, a START event when an admin starts impersonating a user
+-- and an END event when the session is ended before it expires
+CREATE TABLE IF NOT EXISTS "ImpersonationAudit" (
+    "id" BIGSERIAL PRIMARY KEY,
+    "sessionXid" VARCHAR(64) NOT NULL,
+    "event" VARCHAR(16) NOT NULL,
+    "adminId" VARCHAR(64) NOT NULL,
+    "userXid" VARCHAR(64) NOT NULL,
+    "reason" TEXT NOT NULL DEFAULT '',
+    "clientIp" VARCHAR(64) NOT NULL DEFAULT '',
+    "actor" JSONB NOT NULL,
+    "metadata" JSONB NOT NULL DEFAULT '{}',
+    "createdAt" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_impersonation_audit_session_xid ON "ImpersonationAudit"("sessionXid");
+CREATE INDEX IF NOT EXISTS idx_impersonation_audit_admin_id ON "ImpersonationAudit"("adminId");
+CREATE INDEX IF NOT EXISTS idx_impersonation_audit_user_xid ON "ImpersonationAudit"("userXid");
@@ -38,6 +38,11 @@ func (c *Client) Keys(pattern string) ([]string, error) {
 	return c.rdb.Keys(c.ctx, pattern).Result()
 }
 
+// SMembers returns all members of a set.
+func (c *Client) SMembers(key string) ([]string, error) {
+	return c.rdb.SMembers(c.ctx, key).Result()
+}
+
 // IsNil checks if error is redis.Nil (key not found).
 func IsNil(err error) bool {
 	return err == redis.Nil
@@ -2,6 +2,8 @@ package redis
 
 import (
 	"time"
+
+	"github.com/redis/go-redis/v9"
 )
 
 // Set stores a key-value pair with optional expiration.
@@ -10,6 +12,16 @@ func (c *Client) Set(key string, value interface{}, expiration time.Duration) er
 	return c.rdb.Set(c.ctx, key, value, expiration).Err()
 }
 
+// Replace overwrites the value of an existing key while keeping its expiration (requires Redis >= 6.0).
+// Returns false if key doesn't exist.
+func (c *Client) Replace(key string, value interface{}) (bool, error) {
+	err := c.rdb.SetArgs(c.ctx, key, value, redis.SetArgs{Mode: "XX", KeepTTL: true}).Err()
+	if err == redis.Nil {
+		return false, nil
+	}
+	return err == nil, err
+}
+
 // SetNX sets a key only if it doesn't exist (atomic). Returns true if set, false if key exists.
 func (c *Client) SetNX(key string, value interface{}, expiration time.Duration) (bool, error) {
 	return c.rdb.SetNX(c.ctx, key, value, expiration).Result()
@@ -20,6 +32,11 @@ func (c *Client) SetEX(key string, value interface{}, expiration time.Duration)
 	return c.rdb.SetEx(c.ctx, key, value, expiration).Err()
 }
 
+// GetDel gets the value of a key and deletes it (atomic). Returns redis.Nil error if key doesn't exist.
+func (c *Client) GetDel(key string) (string, error) {
+	return c.rdb.GetDel(c.ctx, key).Result()
+}
+
 // Del deletes one or more keys. Returns the number of keys deleted.
 func (c *Client) Del(keys ...string) (int64, error) {
 	return c.rdb.Del(c.ctx, keys...).Result()
@@ -49,3 +66,13 @@ func (c *Client) Decr(key string) (int64, error) {
 func (c *Client) DecrBy(key string, n int64) (int64, error) {
 	return c.rdb.DecrBy(c.ctx, key, n).Result()
 }
+
+// SAdd adds members to a set. Returns the number of members added.
+func (c *Client) SAdd(key string, members ...interface{}) (int64, error) {
+	return c.rdb.SAdd(c.ctx, key, members...).Result()
+}
+
+// SRem removes members from a set. Returns the number of members removed.
+func (c *Client) SRem(key string, members ...interface{}) (int64, error) {
+	return c.rdb.SRem(c.ctx, key, members...).Result()
+}